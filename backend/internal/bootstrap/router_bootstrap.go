@@ -1,7 +1,9 @@
 package bootstrap
 
 import (
+	"crypto/tls"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -62,7 +64,7 @@ func initRouter(db *gorm.DB, appConfigService *service.AppConfigService) {
 
 	// Set up API routes
 	apiGroup := r.Group("/api")
-	controller.NewWebauthnController(apiGroup, jwtAuthMiddleware, middleware.NewRateLimitMiddleware(), webauthnService, appConfigService)
+	controller.NewWebauthnController(apiGroup, jwtAuthMiddleware, middleware.NewRateLimitMiddleware(), webauthnService, appConfigService, oidcService)
 	controller.NewOidcController(apiGroup, jwtAuthMiddleware, fileSizeLimitMiddleware, oidcService, jwtService)
 	controller.NewUserController(apiGroup, jwtAuthMiddleware, middleware.NewRateLimitMiddleware(), userService, appConfigService)
 	controller.NewAppConfigController(apiGroup, jwtAuthMiddleware, appConfigService, emailService, ldapService)
@@ -77,10 +79,23 @@ func initRouter(db *gorm.DB, appConfigService *service.AppConfigService) {
 
 	// Set up base routes
 	baseGroup := r.Group("/")
-	controller.NewWellKnownController(baseGroup, jwtService)
+	controller.NewWellKnownController(baseGroup, jwtService, oidcService)
 
 	// Run the server
-	if err := r.Run(common.EnvConfig.Host + ":" + common.EnvConfig.Port); err != nil {
+	addr := common.EnvConfig.Host + ":" + common.EnvConfig.Port
+	if common.EnvConfig.TlsCertFile != "" {
+		// Client certificates are requested but verified by the OIDC service because
+		// clients can also authenticate with self-signed certificates
+		server := &http.Server{
+			Addr:      addr,
+			Handler:   r,
+			TLSConfig: &tls.Config{ClientAuth: tls.RequestClientCert},
+		}
+		err = server.ListenAndServeTLS(common.EnvConfig.TlsCertFile, common.EnvConfig.TlsKeyFile)
+	} else {
+		err = r.Run(addr)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
@@ -28,6 +28,13 @@ type EnvConfigSchema struct {
 	GeoLiteDBPath            string     `env:"GEOLITE_DB_PATH"`
 	GeoLiteDBUrl             string     `env:"GEOLITE_DB_URL"`
 	UiConfigDisabled         bool       `env:"PUBLIC_UI_CONFIG_DISABLED"`
+
+	// Mutual TLS for client authentication as defined by RFC 8705
+	TlsCertFile                 string   `env:"TLS_CERT_FILE"`
+	TlsKeyFile                  string   `env:"TLS_KEY_FILE"`
+	TlsClientCAFile             string   `env:"TLS_CLIENT_CA_FILE"`
+	TlsClientCertHeader         string   `env:"TLS_CLIENT_CERT_HEADER"`
+	TlsClientCertTrustedProxies []string `env:"TLS_CLIENT_CERT_TRUSTED_PROXIES"`
 }
 
 var EnvConfig = &EnvConfigSchema{
@@ -61,4 +68,12 @@ func init() {
 	if EnvConfig.DbProvider == DbProviderSqlite && EnvConfig.SqliteDBPath == "" {
 		log.Fatal("Missing SQLITE_DB_PATH environment variable")
 	}
+
+	if (EnvConfig.TlsCertFile == "") != (EnvConfig.TlsKeyFile == "") {
+		log.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if EnvConfig.TlsClientCertHeader != "" && len(EnvConfig.TlsClientCertTrustedProxies) == 0 {
+		log.Fatal("Missing TLS_CLIENT_CERT_TRUSTED_PROXIES environment variable")
+	}
 }
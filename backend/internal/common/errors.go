@@ -10,6 +10,13 @@ type AppError interface {
 	HttpStatusCode() int
 }
 
+// OAuthError is an AppError that has to be returned with an error code defined by the OAuth specifications
+// because clients rely on it, e.g. to keep polling the token endpoint
+type OAuthError interface {
+	AppError
+	OAuthErrorCode() string
+}
+
 // Custom error types for various conditions
 
 type AlreadyInUseError struct {
@@ -45,23 +52,27 @@ func (e *OidcMissingAuthorizationError) HttpStatusCode() int { return http.Statu
 
 type OidcGrantTypeNotSupportedError struct{}
 
-func (e *OidcGrantTypeNotSupportedError) Error() string       { return "grant type not supported" }
-func (e *OidcGrantTypeNotSupportedError) HttpStatusCode() int { return 400 }
+func (e *OidcGrantTypeNotSupportedError) Error() string          { return "grant type not supported" }
+func (e *OidcGrantTypeNotSupportedError) HttpStatusCode() int    { return 400 }
+func (e *OidcGrantTypeNotSupportedError) OAuthErrorCode() string { return "unsupported_grant_type" }
 
 type OidcMissingClientCredentialsError struct{}
 
-func (e *OidcMissingClientCredentialsError) Error() string       { return "client id or secret not provided" }
-func (e *OidcMissingClientCredentialsError) HttpStatusCode() int { return 400 }
+func (e *OidcMissingClientCredentialsError) Error() string          { return "client id or secret not provided" }
+func (e *OidcMissingClientCredentialsError) HttpStatusCode() int    { return 400 }
+func (e *OidcMissingClientCredentialsError) OAuthErrorCode() string { return "invalid_client" }
 
 type OidcClientSecretInvalidError struct{}
 
-func (e *OidcClientSecretInvalidError) Error() string       { return "invalid client secret" }
-func (e *OidcClientSecretInvalidError) HttpStatusCode() int { return 400 }
+func (e *OidcClientSecretInvalidError) Error() string          { return "invalid client secret" }
+func (e *OidcClientSecretInvalidError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcClientSecretInvalidError) OAuthErrorCode() string { return "invalid_client" }
 
 type OidcInvalidAuthorizationCodeError struct{}
 
-func (e *OidcInvalidAuthorizationCodeError) Error() string       { return "invalid authorization code" }
-func (e *OidcInvalidAuthorizationCodeError) HttpStatusCode() int { return 400 }
+func (e *OidcInvalidAuthorizationCodeError) Error() string          { return "invalid authorization code" }
+func (e *OidcInvalidAuthorizationCodeError) HttpStatusCode() int    { return 400 }
+func (e *OidcInvalidAuthorizationCodeError) OAuthErrorCode() string { return "invalid_grant" }
 
 type OidcInvalidCallbackURLError struct{}
 
@@ -140,6 +151,22 @@ func (e *ReservedClaimError) Error() string {
 }
 func (e *ReservedClaimError) HttpStatusCode() int { return http.StatusBadRequest }
 
+type InvalidScopeNameError struct{}
+
+func (e *InvalidScopeNameError) Error() string {
+	return "scope names can't be empty or contain spaces, quotes or backslashes"
+}
+func (e *InvalidScopeNameError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type ReservedScopeError struct {
+	Name string
+}
+
+func (e *ReservedScopeError) Error() string {
+	return fmt.Sprintf("Scope %s is reserved and can't be used", e.Name)
+}
+func (e *ReservedScopeError) HttpStatusCode() int { return http.StatusBadRequest }
+
 type DuplicateClaimError struct {
 	Key string
 }
@@ -161,7 +188,8 @@ type OidcInvalidCodeVerifierError struct{}
 func (e *OidcInvalidCodeVerifierError) Error() string {
 	return "Invalid code verifier"
 }
-func (e *OidcInvalidCodeVerifierError) HttpStatusCode() int { return http.StatusBadRequest }
+func (e *OidcInvalidCodeVerifierError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidCodeVerifierError) OAuthErrorCode() string { return "invalid_grant" }
 
 type OidcMissingCodeChallengeError struct{}
 
@@ -211,3 +239,296 @@ func (e *UiConfigDisabledError) Error() string {
 	return "The configuration can't be changed since the UI configuration is disabled"
 }
 func (e *UiConfigDisabledError) HttpStatusCode() int { return http.StatusForbidden }
+
+type OidcInvalidRefreshTokenError struct{}
+
+func (e *OidcInvalidRefreshTokenError) Error() string          { return "invalid refresh token" }
+func (e *OidcInvalidRefreshTokenError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidRefreshTokenError) OAuthErrorCode() string { return "invalid_grant" }
+
+type OidcUnauthorizedClientError struct{}
+
+func (e *OidcUnauthorizedClientError) Error() string {
+	return "client is not allowed to use this grant type"
+}
+func (e *OidcUnauthorizedClientError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcUnauthorizedClientError) OAuthErrorCode() string { return "unauthorized_client" }
+
+type OidcInvalidScopeError struct{}
+
+func (e *OidcInvalidScopeError) Error() string          { return "requested scope is invalid" }
+func (e *OidcInvalidScopeError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidScopeError) OAuthErrorCode() string { return "invalid_scope" }
+
+type OidcInvalidUserCodeError struct{}
+
+func (e *OidcInvalidUserCodeError) Error() string       { return "invalid or expired code" }
+func (e *OidcInvalidUserCodeError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidDeviceCodeError struct{}
+
+func (e *OidcInvalidDeviceCodeError) Error() string          { return "invalid device code" }
+func (e *OidcInvalidDeviceCodeError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidDeviceCodeError) OAuthErrorCode() string { return "invalid_grant" }
+
+type OidcDeviceCodeExpiredError struct{}
+
+func (e *OidcDeviceCodeExpiredError) Error() string          { return "device code expired" }
+func (e *OidcDeviceCodeExpiredError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcDeviceCodeExpiredError) OAuthErrorCode() string { return "expired_token" }
+
+type OidcAuthorizationPendingError struct{}
+
+func (e *OidcAuthorizationPendingError) Error() string {
+	return "the user hasn't completed the authorization yet"
+}
+func (e *OidcAuthorizationPendingError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcAuthorizationPendingError) OAuthErrorCode() string { return "authorization_pending" }
+
+type OidcSlowDownError struct{}
+
+func (e *OidcSlowDownError) Error() string          { return "polling too frequently" }
+func (e *OidcSlowDownError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcSlowDownError) OAuthErrorCode() string { return "slow_down" }
+
+type OidcInvalidInitialAccessTokenError struct{}
+
+func (e *OidcInvalidInitialAccessTokenError) Error() string {
+	return "initial access token is invalid or expired"
+}
+func (e *OidcInvalidInitialAccessTokenError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcInvalidInitialAccessTokenError) OAuthErrorCode() string { return "invalid_token" }
+
+type OidcInvalidRegistrationAccessTokenError struct{}
+
+func (e *OidcInvalidRegistrationAccessTokenError) Error() string {
+	return "registration access token is invalid"
+}
+func (e *OidcInvalidRegistrationAccessTokenError) HttpStatusCode() int {
+	return http.StatusUnauthorized
+}
+func (e *OidcInvalidRegistrationAccessTokenError) OAuthErrorCode() string { return "invalid_token" }
+
+type OidcInvalidClientMetadataError struct {
+	Message string
+}
+
+func (e *OidcInvalidClientMetadataError) Error() string          { return e.Message }
+func (e *OidcInvalidClientMetadataError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidClientMetadataError) OAuthErrorCode() string { return "invalid_client_metadata" }
+
+type OidcInvalidRedirectURIError struct {
+	URI string
+}
+
+func (e *OidcInvalidRedirectURIError) Error() string {
+	return fmt.Sprintf("redirect URI %s is invalid", e.URI)
+}
+func (e *OidcInvalidRedirectURIError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidRedirectURIError) OAuthErrorCode() string { return "invalid_redirect_uri" }
+
+type OidcPushedAuthorizationRequestRequiredError struct{}
+
+func (e *OidcPushedAuthorizationRequestRequiredError) Error() string {
+	return "the client requires pushed authorization requests"
+}
+func (e *OidcPushedAuthorizationRequestRequiredError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+func (e *OidcPushedAuthorizationRequestRequiredError) OAuthErrorCode() string {
+	return "invalid_request"
+}
+
+type OidcInvalidRequestURIError struct{}
+
+func (e *OidcInvalidRequestURIError) Error() string          { return "request URI is invalid or expired" }
+func (e *OidcInvalidRequestURIError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidRequestURIError) OAuthErrorCode() string { return "invalid_request_uri" }
+
+type OidcInvalidRequestURIsError struct{}
+
+func (e *OidcInvalidRequestURIsError) Error() string {
+	return "request URIs must be absolute HTTPS URLs without a fragment"
+}
+func (e *OidcInvalidRequestURIsError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidLogoutURIError struct{}
+
+func (e *OidcInvalidLogoutURIError) Error() string {
+	return "logout URIs must be absolute HTTP or HTTPS URLs without a fragment"
+}
+func (e *OidcInvalidLogoutURIError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidSubjectTypeError struct {
+	Message string
+}
+
+func (e *OidcInvalidSubjectTypeError) Error() string       { return e.Message }
+func (e *OidcInvalidSubjectTypeError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidResponseEncryptionError struct {
+	Message string
+}
+
+func (e *OidcInvalidResponseEncryptionError) Error() string       { return e.Message }
+func (e *OidcInvalidResponseEncryptionError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidResourceIdentifierError struct{}
+
+func (e *OidcInvalidResourceIdentifierError) Error() string {
+	return "identifier must be an absolute URI without a fragment that doesn't point to Pocket ID"
+}
+func (e *OidcInvalidResourceIdentifierError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcUnsupportedResponseTypeError struct{}
+
+func (e *OidcUnsupportedResponseTypeError) Error() string       { return "response type is not supported" }
+func (e *OidcUnsupportedResponseTypeError) HttpStatusCode() int { return http.StatusBadRequest }
+func (e *OidcUnsupportedResponseTypeError) OAuthErrorCode() string {
+	return "unsupported_response_type"
+}
+
+type OidcResponseTypeNotAllowedError struct{}
+
+func (e *OidcResponseTypeNotAllowedError) Error() string {
+	return "client is not allowed to use this response type"
+}
+func (e *OidcResponseTypeNotAllowedError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcResponseTypeNotAllowedError) OAuthErrorCode() string { return "unauthorized_client" }
+
+type OidcInvalidRequestObjectError struct{}
+
+func (e *OidcInvalidRequestObjectError) Error() string          { return "request object is invalid" }
+func (e *OidcInvalidRequestObjectError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidRequestObjectError) OAuthErrorCode() string { return "invalid_request_object" }
+
+type OidcSignedRequestObjectRequiredError struct{}
+
+func (e *OidcSignedRequestObjectRequiredError) Error() string {
+	return "the client requires a signed request object"
+}
+func (e *OidcSignedRequestObjectRequiredError) HttpStatusCode() int { return http.StatusBadRequest }
+func (e *OidcSignedRequestObjectRequiredError) OAuthErrorCode() string {
+	return "invalid_request"
+}
+
+type OidcInvalidJwksError struct{}
+
+func (e *OidcInvalidJwksError) Error() string       { return "the JWKS is invalid" }
+func (e *OidcInvalidJwksError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcInvalidClientAssertionError struct{}
+
+func (e *OidcInvalidClientAssertionError) Error() string          { return "client assertion is invalid" }
+func (e *OidcInvalidClientAssertionError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcInvalidClientAssertionError) OAuthErrorCode() string { return "invalid_client" }
+
+type OidcInvalidClientAuthMethodError struct {
+	Method string
+}
+
+func (e *OidcInvalidClientAuthMethodError) Error() string {
+	return fmt.Sprintf("the client has to authenticate with %s", e.Method)
+}
+func (e *OidcInvalidClientAuthMethodError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcInvalidClientAuthMethodError) OAuthErrorCode() string { return "invalid_client" }
+
+type OidcUnsupportedTokenEndpointAuthMethodError struct{}
+
+func (e *OidcUnsupportedTokenEndpointAuthMethodError) Error() string {
+	return "token endpoint auth method is not supported"
+}
+func (e *OidcUnsupportedTokenEndpointAuthMethodError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+type OidcClientKeysRequiredError struct {
+	Method string
+}
+
+func (e *OidcClientKeysRequiredError) Error() string {
+	return fmt.Sprintf("a JWKS or a JWKS URI is required for %s", e.Method)
+}
+func (e *OidcClientKeysRequiredError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcClientSubjectDNRequiredError struct{}
+
+func (e *OidcClientSubjectDNRequiredError) Error() string {
+	return "a certificate subject DN is required for tls_client_auth"
+}
+func (e *OidcClientSubjectDNRequiredError) HttpStatusCode() int { return http.StatusBadRequest }
+
+type OidcMissingClientCertificateError struct{}
+
+func (e *OidcMissingClientCertificateError) Error() string          { return "client certificate not provided" }
+func (e *OidcMissingClientCertificateError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcMissingClientCertificateError) OAuthErrorCode() string { return "invalid_client" }
+
+type OidcInvalidClientCertificateError struct{}
+
+func (e *OidcInvalidClientCertificateError) Error() string          { return "client certificate is invalid" }
+func (e *OidcInvalidClientCertificateError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcInvalidClientCertificateError) OAuthErrorCode() string { return "invalid_client" }
+
+type OidcInvalidDpopProofError struct{}
+
+func (e *OidcInvalidDpopProofError) Error() string          { return "DPoP proof is invalid" }
+func (e *OidcInvalidDpopProofError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidDpopProofError) OAuthErrorCode() string { return "invalid_dpop_proof" }
+
+type OidcDpopProofRequiredError struct{}
+
+func (e *OidcDpopProofRequiredError) Error() string          { return "the client has to send a DPoP proof" }
+func (e *OidcDpopProofRequiredError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcDpopProofRequiredError) OAuthErrorCode() string { return "invalid_dpop_proof" }
+
+type OidcUseDpopNonceError struct{}
+
+func (e *OidcUseDpopNonceError) Error() string {
+	return "the DPoP proof has to contain the nonce of the DPoP-Nonce header"
+}
+func (e *OidcUseDpopNonceError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcUseDpopNonceError) OAuthErrorCode() string { return "use_dpop_nonce" }
+
+// OidcDpopNonceRequiredError is returned by resource endpoints if the DPoP proof doesn't contain a valid nonce (RFC 9449 section 9)
+type OidcDpopNonceRequiredError struct{}
+
+func (e *OidcDpopNonceRequiredError) Error() string {
+	return "resource server requires nonce in DPoP proof"
+}
+func (e *OidcDpopNonceRequiredError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcDpopNonceRequiredError) OAuthErrorCode() string { return "use_dpop_nonce" }
+
+type OidcInvalidTargetError struct{}
+
+func (e *OidcInvalidTargetError) Error() string {
+	return "the requested audience or resource is invalid or not allowed for the client"
+}
+func (e *OidcInvalidTargetError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidTargetError) OAuthErrorCode() string { return "invalid_target" }
+
+type OidcInvalidSubjectTokenError struct{}
+
+func (e *OidcInvalidSubjectTokenError) Error() string          { return "subject token is invalid" }
+func (e *OidcInvalidSubjectTokenError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidSubjectTokenError) OAuthErrorCode() string { return "invalid_grant" }
+
+type OidcInvalidRequestError struct {
+	Message string
+}
+
+func (e *OidcInvalidRequestError) Error() string          { return e.Message }
+func (e *OidcInvalidRequestError) HttpStatusCode() int    { return http.StatusBadRequest }
+func (e *OidcInvalidRequestError) OAuthErrorCode() string { return "invalid_request" }
+
+type OidcLoginRequiredError struct{}
+
+func (e *OidcLoginRequiredError) Error() string          { return "the user has to sign in again" }
+func (e *OidcLoginRequiredError) HttpStatusCode() int    { return http.StatusUnauthorized }
+func (e *OidcLoginRequiredError) OAuthErrorCode() string { return "login_required" }
+
+type OidcConsentRequiredError struct{}
+
+func (e *OidcConsentRequiredError) Error() string          { return "the user has to authorize the client" }
+func (e *OidcConsentRequiredError) HttpStatusCode() int    { return http.StatusForbidden }
+func (e *OidcConsentRequiredError) OAuthErrorCode() string { return "consent_required" }
@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"crypto/x509"
+	"errors"
 	"github.com/pocket-id/pocket-id/backend/internal/common"
 	"github.com/pocket-id/pocket-id/backend/internal/utils/cookie"
+	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -18,13 +22,25 @@ import (
 func NewOidcController(group *gin.RouterGroup, jwtAuthMiddleware *middleware.JwtAuthMiddleware, fileSizeLimitMiddleware *middleware.FileSizeLimitMiddleware, oidcService *service.OidcService, jwtService *service.JwtService) {
 	oc := &OidcController{oidcService: oidcService, jwtService: jwtService}
 
-	group.POST("/oidc/authorize", jwtAuthMiddleware.Add(false), oc.authorizeHandler)
+	// The authorize endpoint has to handle signed out users itself, e.g. to return login_required for prompt=none
+	optionalJwtAuthMiddleware := middleware.NewJwtAuthMiddleware(jwtService, true)
+
+	group.POST("/oidc/authorize", optionalJwtAuthMiddleware.Add(false), oc.authorizeHandler)
 	group.POST("/oidc/authorization-required", jwtAuthMiddleware.Add(false), oc.authorizationConfirmationRequiredHandler)
 
+	group.GET("/oidc/authorization-request", oc.resolveAuthorizationRequestHandler)
+	group.POST("/oidc/par", oc.pushedAuthorizationRequestHandler)
+
+	group.POST("/oidc/device/authorize", oc.deviceAuthorizationHandler)
+	group.GET("/oidc/device/info", jwtAuthMiddleware.Add(false), oc.getDeviceCodeInfoHandler)
+	group.POST("/oidc/device/verify", jwtAuthMiddleware.Add(false), oc.verifyDeviceCodeHandler)
+
 	group.POST("/oidc/token", oc.createTokensHandler)
+	group.POST("/oidc/introspect", oc.introspectTokenHandler)
+	group.POST("/oidc/revoke", oc.revokeTokenHandler)
 	group.GET("/oidc/userinfo", oc.userInfoHandler)
-	group.POST("/oidc/end-session", oc.EndSessionHandler)
-	group.GET("/oidc/end-session", oc.EndSessionHandler)
+	group.POST("/oidc/end-session", optionalJwtAuthMiddleware.Add(false), oc.EndSessionHandler)
+	group.GET("/oidc/end-session", optionalJwtAuthMiddleware.Add(false), oc.EndSessionHandler)
 
 	group.GET("/oidc/clients", jwtAuthMiddleware.Add(true), oc.listClientsHandler)
 	group.POST("/oidc/clients", jwtAuthMiddleware.Add(true), oc.createClientHandler)
@@ -35,6 +51,34 @@ func NewOidcController(group *gin.RouterGroup, jwtAuthMiddleware *middleware.Jwt
 	group.PUT("/oidc/clients/:id/allowed-user-groups", jwtAuthMiddleware.Add(true), oc.updateAllowedUserGroupsHandler)
 	group.POST("/oidc/clients/:id/secret", jwtAuthMiddleware.Add(true), oc.createClientSecretHandler)
 
+	group.GET("/oidc/initial-access-tokens", jwtAuthMiddleware.Add(true), oc.listInitialAccessTokensHandler)
+	group.POST("/oidc/initial-access-tokens", jwtAuthMiddleware.Add(true), oc.createInitialAccessTokenHandler)
+	group.DELETE("/oidc/initial-access-tokens/:id", jwtAuthMiddleware.Add(true), oc.deleteInitialAccessTokenHandler)
+
+	group.GET("/oidc/resources", jwtAuthMiddleware.Add(true), oc.listResourcesHandler)
+	group.POST("/oidc/resources", jwtAuthMiddleware.Add(true), oc.createResourceHandler)
+	group.GET("/oidc/resources/:id", jwtAuthMiddleware.Add(true), oc.getResourceHandler)
+	group.PUT("/oidc/resources/:id", jwtAuthMiddleware.Add(true), oc.updateResourceHandler)
+	group.DELETE("/oidc/resources/:id", jwtAuthMiddleware.Add(true), oc.deleteResourceHandler)
+	group.PUT("/oidc/resources/:id/allowed-clients", jwtAuthMiddleware.Add(true), oc.updateResourceAllowedClientsHandler)
+
+	group.GET("/oidc/scopes", oc.listScopesHandler)
+	group.POST("/oidc/scopes", jwtAuthMiddleware.Add(true), oc.createScopeHandler)
+	group.PUT("/oidc/scopes/:id", jwtAuthMiddleware.Add(true), oc.updateScopeHandler)
+	group.DELETE("/oidc/scopes/:id", jwtAuthMiddleware.Add(true), oc.deleteScopeHandler)
+
+	group.POST("/oidc/register", oc.registerClientHandler)
+	group.GET("/oidc/register/:id", oc.getRegisteredClientHandler)
+	group.PUT("/oidc/register/:id", oc.updateRegisteredClientHandler)
+	group.DELETE("/oidc/register/:id", oc.deleteRegisteredClientHandler)
+
+	group.DELETE("/oidc/users/:id/sessions", jwtAuthMiddleware.Add(true), oc.revokeUserSessionsHandler)
+
+	group.GET("/users/me/authorized-clients", jwtAuthMiddleware.Add(false), oc.listOwnAuthorizedClientsHandler)
+	group.DELETE("/users/me/authorized-clients/:clientId", jwtAuthMiddleware.Add(false), oc.revokeOwnAuthorizedClientHandler)
+	group.GET("/users/:id/authorized-clients", jwtAuthMiddleware.Add(true), oc.listAuthorizedClientsHandler)
+	group.DELETE("/users/:id/authorized-clients/:clientId", jwtAuthMiddleware.Add(true), oc.revokeAuthorizedClientHandler)
+
 	group.GET("/oidc/clients/:id/logo", oc.getClientLogoHandler)
 	group.DELETE("/oidc/clients/:id/logo", oc.deleteClientLogoHandler)
 	group.POST("/oidc/clients/:id/logo", jwtAuthMiddleware.Add(true), fileSizeLimitMiddleware.Add(2<<20), oc.updateClientLogoHandler)
@@ -52,20 +96,66 @@ func (oc *OidcController) authorizeHandler(c *gin.Context) {
 		return
 	}
 
-	code, callbackURL, err := oc.oidcService.Authorize(input, c.GetString("userID"), c.ClientIP(), c.Request.UserAgent())
+	// The user doesn't have to be signed in because the client expects an error response for prompt=none
+	response, err := oc.oidcService.Authorize(input, c.GetString("userID"), c.GetString("loginSessionID"), c.GetTime("authTime"), c.GetStringSlice("amr"), c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	response := dto.AuthorizeOidcClientResponseDto{
-		Code:        code,
-		CallbackURL: callbackURL,
+	if response.ResponseMode == "form_post" {
+		var html strings.Builder
+		if err := formPostTemplate.Execute(&html, response); err != nil {
+			c.Error(err)
+			return
+		}
+		response.FormPostHTML = html.String()
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+func (oc *OidcController) pushedAuthorizationRequestHandler(c *gin.Context) {
+	var input dto.OidcPushedAuthorizationRequestDto
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	setClientCredentialsFromRequest(c, &input.OidcClientCredentialsDto)
+
+	response, err := oc.oidcService.CreatePushedAuthorizationRequest(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// resolveAuthorizationRequestHandler returns the parameters of a pushed authorization request or a signed request object
+func (oc *OidcController) resolveAuthorizationRequestHandler(c *gin.Context) {
+	var input dto.OidcAuthorizationRequestDto
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	request, err := oc.oidcService.ResolveAuthorizationRequest(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var parametersDto dto.OidcAuthorizationRequestParametersDto
+	if err := dto.MapStruct(request, &parametersDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, parametersDto)
+}
+
 func (oc *OidcController) authorizationConfirmationRequiredHandler(c *gin.Context) {
 	var input dto.AuthorizationRequiredDto
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -73,7 +163,7 @@ func (oc *OidcController) authorizationConfirmationRequiredHandler(c *gin.Contex
 		return
 	}
 
-	hasAuthorizedClient, err := oc.oidcService.HasAuthorizedClient(input.ClientID, c.GetString("userID"), input.Scope)
+	hasAuthorizedClient, err := oc.oidcService.HasAuthorizedClient(input.ClientID, c.GetString("userID"), input.Scope, input.Claims)
 	if err != nil {
 		c.Error(err)
 		return
@@ -93,37 +183,170 @@ func (oc *OidcController) createTokensHandler(c *gin.Context) {
 		return
 	}
 
-	clientID := input.ClientID
-	clientSecret := input.ClientSecret
+	setClientCredentialsFromRequest(c, &input.OidcClientCredentialsDto)
+	input.Dpop = dpopProof(c)
 
-	// Client id and secret can also be passed over the Authorization header
-	if clientID == "" && clientSecret == "" {
-		clientID, clientSecret, _ = c.Request.BasicAuth()
+	// Clients that send a DPoP proof need a nonce for their next proof
+	if input.Dpop.Proof != "" {
+		c.Header("DPoP-Nonce", oc.oidcService.CreateDpopNonce())
 	}
 
-	idToken, accessToken, err := oc.oidcService.CreateTokens(input.Code, input.GrantType, clientID, clientSecret, input.CodeVerifier)
+	tokens, err := oc.oidcService.CreateTokens(input, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id_token": idToken, "access_token": accessToken, "token_type": "Bearer"})
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (oc *OidcController) deviceAuthorizationHandler(c *gin.Context) {
+	var input dto.OidcDeviceAuthorizationRequestDto
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	setClientCredentialsFromRequest(c, &input.OidcClientCredentialsDto)
+
+	response, err := oc.oidcService.CreateDeviceAuthorization(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (oc *OidcController) getDeviceCodeInfoHandler(c *gin.Context) {
+	deviceCode, err := oc.oidcService.GetDeviceCode(c.Query("code"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	hasAuthorizedClient, err := oc.oidcService.HasAuthorizedClient(deviceCode.ClientID, c.GetString("userID"), deviceCode.Scope, "")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var clientDto dto.PublicOidcClientDto
+	if err := dto.MapStruct(deviceCode.Client, &clientDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OidcDeviceCodeInfoDto{
+		Client:                clientDto,
+		Scope:                 deviceCode.Scope,
+		AuthorizationRequired: !hasAuthorizedClient,
+	})
+}
+
+func (oc *OidcController) verifyDeviceCodeHandler(c *gin.Context) {
+	var input dto.OidcVerifyDeviceCodeDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := oc.oidcService.VerifyDeviceCode(input.UserCode, c.GetString("userID"), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (oc *OidcController) introspectTokenHandler(c *gin.Context) {
+	var input dto.OidcIntrospectDto
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	setClientCredentialsFromRequest(c, &input.OidcClientCredentialsDto)
+
+	response, err := oc.oidcService.IntrospectToken(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (oc *OidcController) revokeTokenHandler(c *gin.Context) {
+	var input dto.OidcRevokeTokenDto
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	setClientCredentialsFromRequest(c, &input.OidcClientCredentialsDto)
+
+	if err := oc.oidcService.RevokeToken(input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
 }
 
 func (oc *OidcController) userInfoHandler(c *gin.Context) {
-	token := strings.Split(c.GetHeader("Authorization"), " ")[1]
-	jwtClaims, err := oc.jwtService.VerifyOauthAccessToken(token)
+	// The token is either sent as a bearer or a DPoP token
+	scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	jwtClaims, err := oc.oidcService.VerifyOauthAccessToken(token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokenRequest := dto.OidcAccessTokenRequestDto{
+		AccessToken:         token,
+		AuthorizationScheme: scheme,
+		ClientCertificate:   clientCertificate(c),
+		Dpop:                dpopProof(c),
+	}
+
+	// Clients that use DPoP need a nonce for their next proof
+	if strings.EqualFold(scheme, "DPoP") {
+		c.Header("DPoP-Nonce", oc.oidcService.CreateDpopNonce())
+	}
+
+	if err := oc.oidcService.VerifyTokenConfirmation(jwtClaims, tokenRequest); err != nil {
+		// The client has to retry with the nonce of the DPoP-Nonce header
+		var nonceErr *common.OidcDpopNonceRequiredError
+		if errors.As(err, &nonceErr) {
+			c.Header("WWW-Authenticate", `DPoP error="use_dpop_nonce", error_description="`+nonceErr.Error()+`"`)
+		}
+		c.Error(err)
+		return
+	}
+
+	userID, err := oc.oidcService.UserIDFromSubject(jwtClaims.Subject)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	clientId := service.AuthorizedClientID(jwtClaims)
+	claims, err := oc.oidcService.GetUserClaimsForClient(userID, clientId, service.ClaimsTargetUserinfo)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	userID := jwtClaims.Subject
-	clientId := jwtClaims.Audience[0]
-	claims, err := oc.oidcService.GetUserClaimsForClient(userID, clientId)
+
+	// Some clients require the userinfo as a signed or encrypted JWT
+	userinfoJWT, err := oc.oidcService.CreateUserinfoJWT(clientId, claims)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	if userinfoJWT != "" {
+		c.Data(http.StatusOK, "application/jwt", []byte(userinfoJWT))
+		return
+	}
 
 	c.JSON(http.StatusOK, claims)
 }
@@ -154,6 +377,11 @@ func (oc *OidcController) EndSessionHandler(c *gin.Context) {
 	}
 
 	// The validation was successful, so we can log out and redirect the user to the callback URL without confirmation
+	frontchannelLogoutURLs, err := oc.oidcService.LogoutUser(c.GetString("userID"), c.GetString("loginSessionID"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	cookie.AddAccessTokenCookie(c, 0, "")
 
 	logoutCallbackURL, _ := url.Parse(callbackURL)
@@ -163,7 +391,71 @@ func (oc *OidcController) EndSessionHandler(c *gin.Context) {
 		logoutCallbackURL.RawQuery = q.Encode()
 	}
 
-	c.Redirect(http.StatusFound, logoutCallbackURL.String())
+	if len(frontchannelLogoutURLs) == 0 {
+		c.Redirect(http.StatusFound, logoutCallbackURL.String())
+		return
+	}
+
+	// The clients with a front-channel logout URI have to be loaded in the browser before the user gets redirected
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	err = frontchannelLogoutTemplate.Execute(c.Writer, gin.H{
+		"LogoutURLs":  frontchannelLogoutURLs,
+		"CallbackURL": logoutCallbackURL.String(),
+	})
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+func (oc *OidcController) revokeUserSessionsHandler(c *gin.Context) {
+	if err := oc.oidcService.RevokeUserSessions(c.Param("id"), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (oc *OidcController) listOwnAuthorizedClientsHandler(c *gin.Context) {
+	oc.listAuthorizedClients(c, c.GetString("userID"))
+}
+
+func (oc *OidcController) listAuthorizedClientsHandler(c *gin.Context) {
+	oc.listAuthorizedClients(c, c.Param("id"))
+}
+
+func (oc *OidcController) listAuthorizedClients(c *gin.Context, userID string) {
+	authorizedClients, err := oc.oidcService.ListAuthorizedClients(userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var authorizedClientsDto []dto.AuthorizedOidcClientDto
+	if err := dto.MapStructList(authorizedClients, &authorizedClientsDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authorizedClientsDto)
+}
+
+func (oc *OidcController) revokeOwnAuthorizedClientHandler(c *gin.Context) {
+	oc.revokeAuthorizedClient(c, c.GetString("userID"))
+}
+
+func (oc *OidcController) revokeAuthorizedClientHandler(c *gin.Context) {
+	oc.revokeAuthorizedClient(c, c.Param("id"))
+}
+
+func (oc *OidcController) revokeAuthorizedClient(c *gin.Context, userID string) {
+	if err := oc.oidcService.RevokeAuthorizedClient(userID, c.Param("clientId"), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
 
 func (oc *OidcController) getClientHandler(c *gin.Context) {
@@ -342,3 +634,366 @@ func (oc *OidcController) updateAllowedUserGroupsHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, oidcClientDto)
 }
+
+func (oc *OidcController) listInitialAccessTokensHandler(c *gin.Context) {
+	initialAccessTokens, err := oc.oidcService.ListInitialAccessTokens()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var initialAccessTokensDto []dto.OidcInitialAccessTokenDto
+	if err := dto.MapStructList(initialAccessTokens, &initialAccessTokensDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, initialAccessTokensDto)
+}
+
+func (oc *OidcController) createInitialAccessTokenHandler(c *gin.Context) {
+	var input dto.OidcInitialAccessTokenCreateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	initialAccessToken, token, err := oc.oidcService.CreateInitialAccessToken(input, c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var initialAccessTokenDto dto.OidcInitialAccessTokenWithTokenDto
+	if err := dto.MapStruct(initialAccessToken, &initialAccessTokenDto); err != nil {
+		c.Error(err)
+		return
+	}
+	initialAccessTokenDto.Token = token
+
+	c.JSON(http.StatusCreated, initialAccessTokenDto)
+}
+
+func (oc *OidcController) deleteInitialAccessTokenHandler(c *gin.Context) {
+	if err := oc.oidcService.DeleteInitialAccessToken(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (oc *OidcController) listResourcesHandler(c *gin.Context) {
+	resources, err := oc.oidcService.ListResources()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resourcesDto []dto.OidcResourceDto
+	if err := dto.MapStructList(resources, &resourcesDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourcesDto)
+}
+
+func (oc *OidcController) getResourceHandler(c *gin.Context) {
+	resource, err := oc.oidcService.GetResource(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resourceDto dto.OidcResourceDto
+	if err := dto.MapStruct(resource, &resourceDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceDto)
+}
+
+func (oc *OidcController) createResourceHandler(c *gin.Context) {
+	var input dto.OidcResourceCreateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	resource, err := oc.oidcService.CreateResource(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resourceDto dto.OidcResourceDto
+	if err := dto.MapStruct(resource, &resourceDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resourceDto)
+}
+
+func (oc *OidcController) updateResourceHandler(c *gin.Context) {
+	var input dto.OidcResourceCreateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	resource, err := oc.oidcService.UpdateResource(c.Param("id"), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resourceDto dto.OidcResourceDto
+	if err := dto.MapStruct(resource, &resourceDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceDto)
+}
+
+func (oc *OidcController) deleteResourceHandler(c *gin.Context) {
+	if err := oc.oidcService.DeleteResource(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (oc *OidcController) updateResourceAllowedClientsHandler(c *gin.Context) {
+	var input dto.OidcResourceUpdateAllowedClientsDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	resource, err := oc.oidcService.UpdateResourceAllowedClients(c.Param("id"), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resourceDto dto.OidcResourceDto
+	if err := dto.MapStruct(resource, &resourceDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceDto)
+}
+
+// listScopesHandler returns the custom scopes. It is public because the descriptions are shown to the users on consent.
+func (oc *OidcController) listScopesHandler(c *gin.Context) {
+	scopes, err := oc.oidcService.ListScopes()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var scopesDto []dto.OidcScopeDto
+	if err := dto.MapStructList(scopes, &scopesDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scopesDto)
+}
+
+func (oc *OidcController) createScopeHandler(c *gin.Context) {
+	var input dto.OidcScopeCreateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	scope, err := oc.oidcService.CreateScope(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var scopeDto dto.OidcScopeDto
+	if err := dto.MapStruct(scope, &scopeDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, scopeDto)
+}
+
+func (oc *OidcController) updateScopeHandler(c *gin.Context) {
+	var input dto.OidcScopeCreateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	scope, err := oc.oidcService.UpdateScope(c.Param("id"), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var scopeDto dto.OidcScopeDto
+	if err := dto.MapStruct(scope, &scopeDto); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scopeDto)
+}
+
+func (oc *OidcController) deleteScopeHandler(c *gin.Context) {
+	if err := oc.oidcService.DeleteScope(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (oc *OidcController) registerClientHandler(c *gin.Context) {
+	var input dto.OidcClientRegistrationDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, err := oc.oidcService.RegisterClient(input, bearerToken(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (oc *OidcController) getRegisteredClientHandler(c *gin.Context) {
+	response, err := oc.oidcService.GetRegisteredClient(c.Param("id"), bearerToken(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (oc *OidcController) updateRegisteredClientHandler(c *gin.Context) {
+	var input dto.OidcClientRegistrationDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, err := oc.oidcService.UpdateRegisteredClient(c.Param("id"), bearerToken(c), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (oc *OidcController) deleteRegisteredClientHandler(c *gin.Context) {
+	if err := oc.oidcService.DeleteRegisteredClient(c.Param("id"), bearerToken(c)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// setClientCredentialsFromRequest reads the client id and secret from the Authorization header
+// if the client didn't pass them in the request body and adds the client certificate
+func setClientCredentialsFromRequest(c *gin.Context, credentials *dto.OidcClientCredentialsDto) {
+	if credentials.ClientID == "" && credentials.ClientSecret == "" {
+		credentials.ClientID, credentials.ClientSecret, _ = c.Request.BasicAuth()
+	}
+	credentials.ClientCertificate = clientCertificate(c)
+}
+
+// clientCertificate returns the certificate the client presented over mutual TLS, either directly
+// or forwarded by a trusted reverse proxy
+func clientCertificate(c *gin.Context) *x509.Certificate {
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		return c.Request.TLS.PeerCertificates[0]
+	}
+
+	header := common.EnvConfig.TlsClientCertHeader
+	if header == "" || c.GetHeader(header) == "" {
+		return nil
+	}
+
+	remoteIP, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil || !utils.IsIPInList(remoteIP, common.EnvConfig.TlsClientCertTrustedProxies) {
+		return nil
+	}
+
+	certificate, err := utils.ParseForwardedCertificate(c.GetHeader(header))
+	if err != nil {
+		return nil
+	}
+	return certificate
+}
+
+// dpopProof returns the DPoP proof of the request together with the method and URL it has to be bound to
+func dpopProof(c *gin.Context) dto.OidcDpopProofDto {
+	return dto.OidcDpopProofDto{
+		Proof:      c.GetHeader("DPoP"),
+		HttpMethod: c.Request.Method,
+		HttpURL:    common.EnvConfig.AppURL + c.Request.URL.Path,
+	}
+}
+
+// bearerToken returns the token of the Authorization header or an empty string if there is none
+func bearerToken(c *gin.Context) string {
+	authorizationHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorizationHeader, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(authorizationHeader, "Bearer ")
+}
+
+// formPostTemplate renders the page that posts the authorization response to the callback URL of the client as
+// defined by the OAuth 2.0 Form Post Response Mode. The form is submitted manually if JavaScript is disabled.
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Submit This Form</title></head>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{.CallbackURL}}">
+{{- range $name, $value := .Parameters}}
+<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{- end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>`))
+
+// frontchannelLogoutTemplate renders the logout completion page that loads the front-channel logout URIs of the
+// clients in hidden iframes. The user is redirected to the callback URL once all iframes are loaded or after a timeout.
+var frontchannelLogoutTemplate = template.Must(template.New("frontchannel_logout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Signing Out</title></head>
+<body>
+{{- range .LogoutURLs}}
+<iframe src="{{.}}" style="display:none"></iframe>
+{{- end}}
+<noscript><a href="{{.CallbackURL}}">Continue</a></noscript>
+<script>
+const callbackURL = {{.CallbackURL}};
+const frames = document.querySelectorAll("iframe");
+let pendingFrames = frames.length;
+const redirect = () => window.location.replace(callbackURL);
+frames.forEach((frame) => frame.addEventListener("load", () => --pendingFrames === 0 && redirect()));
+setTimeout(redirect, 5000);
+</script>
+</body>
+</html>`))
@@ -16,8 +16,8 @@ import (
 	"golang.org/x/time/rate"
 )
 
-func NewWebauthnController(group *gin.RouterGroup, jwtAuthMiddleware *middleware.JwtAuthMiddleware, rateLimitMiddleware *middleware.RateLimitMiddleware, webauthnService *service.WebAuthnService, appConfigService *service.AppConfigService) {
-	wc := &WebauthnController{webAuthnService: webauthnService, appConfigService: appConfigService}
+func NewWebauthnController(group *gin.RouterGroup, jwtAuthMiddleware *middleware.JwtAuthMiddleware, rateLimitMiddleware *middleware.RateLimitMiddleware, webauthnService *service.WebAuthnService, appConfigService *service.AppConfigService, oidcService *service.OidcService) {
+	wc := &WebauthnController{webAuthnService: webauthnService, appConfigService: appConfigService, oidcService: oidcService}
 	group.GET("/webauthn/register/start", jwtAuthMiddleware.Add(false), wc.beginRegistrationHandler)
 	group.POST("/webauthn/register/finish", jwtAuthMiddleware.Add(false), wc.verifyRegistrationHandler)
 
@@ -34,6 +34,7 @@ func NewWebauthnController(group *gin.RouterGroup, jwtAuthMiddleware *middleware
 type WebauthnController struct {
 	webAuthnService  *service.WebAuthnService
 	appConfigService *service.AppConfigService
+	oidcService      *service.OidcService
 }
 
 func (wc *WebauthnController) beginRegistrationHandler(c *gin.Context) {
@@ -170,6 +171,14 @@ func (wc *WebauthnController) updateCredentialHandler(c *gin.Context) {
 }
 
 func (wc *WebauthnController) logoutHandler(c *gin.Context) {
+	frontchannelLogoutURLs, err := wc.oidcService.LogoutUser(c.GetString("userID"), c.GetString("loginSessionID"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	cookie.AddAccessTokenCookie(c, 0, "")
-	c.Status(http.StatusNoContent)
+
+	// The frontend loads the front-channel logout URLs in iframes to log the user out of the clients
+	c.JSON(http.StatusOK, dto.OidcLogoutResponseDto{FrontchannelLogoutURLs: frontchannelLogoutURLs})
 }
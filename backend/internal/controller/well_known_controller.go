@@ -2,20 +2,23 @@ package controller
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pocket-id/pocket-id/backend/internal/common"
 	"github.com/pocket-id/pocket-id/backend/internal/service"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
 )
 
-func NewWellKnownController(group *gin.RouterGroup, jwtService *service.JwtService) {
-	wkc := &WellKnownController{jwtService: jwtService}
+func NewWellKnownController(group *gin.RouterGroup, jwtService *service.JwtService, oidcService *service.OidcService) {
+	wkc := &WellKnownController{jwtService: jwtService, oidcService: oidcService}
 	group.GET("/.well-known/jwks.json", wkc.jwksHandler)
 	group.GET("/.well-known/openid-configuration", wkc.openIDConfigurationHandler)
 }
 
 type WellKnownController struct {
-	jwtService *service.JwtService
+	jwtService  *service.JwtService
+	oidcService *service.OidcService
 }
 
 func (wkc *WellKnownController) jwksHandler(c *gin.Context) {
@@ -29,19 +32,59 @@ func (wkc *WellKnownController) jwksHandler(c *gin.Context) {
 }
 
 func (wkc *WellKnownController) openIDConfigurationHandler(c *gin.Context) {
+	customScopes, err := wkc.oidcService.ListScopes()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	scopesSupported := slices.Clone(service.BuiltInScopes)
+	for _, customScope := range customScopes {
+		scopesSupported = append(scopesSupported, customScope.Name)
+	}
+
 	appUrl := common.EnvConfig.AppURL
 	config := map[string]interface{}{
-		"issuer":                                appUrl,
-		"authorization_endpoint":                appUrl + "/authorize",
-		"token_endpoint":                        appUrl + "/api/oidc/token",
-		"userinfo_endpoint":                     appUrl + "/api/oidc/userinfo",
-		"end_session_endpoint":                  appUrl + "/api/oidc/end-session",
-		"jwks_uri":                              appUrl + "/.well-known/jwks.json",
-		"scopes_supported":                      []string{"openid", "profile", "email"},
-		"claims_supported":                      []string{"sub", "given_name", "family_name", "name", "email", "email_verified", "preferred_username"},
-		"response_types_supported":              []string{"code", "id_token"},
-		"subject_types_supported":               []string{"public"},
-		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"issuer":                                           appUrl,
+		"authorization_endpoint":                           appUrl + "/authorize",
+		"token_endpoint":                                   appUrl + "/api/oidc/token",
+		"device_authorization_endpoint":                    appUrl + "/api/oidc/device/authorize",
+		"userinfo_endpoint":                                appUrl + "/api/oidc/userinfo",
+		"introspection_endpoint":                           appUrl + "/api/oidc/introspect",
+		"revocation_endpoint":                              appUrl + "/api/oidc/revoke",
+		"registration_endpoint":                            appUrl + "/api/oidc/register",
+		"pushed_authorization_request_endpoint":            appUrl + "/api/oidc/par",
+		"require_pushed_authorization_requests":            false,
+		"request_parameter_supported":                      true,
+		"request_uri_parameter_supported":                  true,
+		"require_request_uri_registration":                 true,
+		"request_object_signing_alg_values_supported":      service.ClientSigningAlgorithms,
+		"token_endpoint_auth_methods_supported":            service.TokenEndpointAuthMethods,
+		"token_endpoint_auth_signing_alg_values_supported": slices.Concat(service.ClientSigningAlgorithms, service.ClientSecretSigningAlgorithms),
+		"revocation_endpoint_auth_methods_supported":       service.TokenEndpointAuthMethods,
+		"dpop_signing_alg_values_supported":                service.ClientSigningAlgorithms,
+		"tls_client_certificate_bound_access_tokens":       true,
+		"backchannel_logout_supported":                     true,
+		"backchannel_logout_session_supported":             true,
+		"frontchannel_logout_supported":                    true,
+		"frontchannel_logout_session_supported":            true,
+		"end_session_endpoint":                             appUrl + "/api/oidc/end-session",
+		"jwks_uri":                                         appUrl + "/.well-known/jwks.json",
+		"scopes_supported":                                 scopesSupported,
+		"claims_supported":                                 []string{"sub", "given_name", "family_name", "name", "email", "email_verified", "preferred_username", "auth_time", "amr", "acr"},
+		"claims_parameter_supported":                       true,
+		"prompt_values_supported":                          service.PromptValues,
+		"acr_values_supported":                             service.AcrValues,
+		"response_types_supported":                         service.ResponseTypes,
+		"response_modes_supported":                         service.ResponseModes,
+		"authorization_signing_alg_values_supported":       []string{"RS256"},
+		"grant_types_supported":                            []string{"authorization_code", "refresh_token", "client_credentials", "urn:ietf:params:oauth:grant-type:device_code", "urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_types_supported":                          service.SubjectTypes,
+		"id_token_signing_alg_values_supported":            service.IDTokenSigningAlgorithms,
+		"id_token_encryption_alg_values_supported":         utils.JWEKeyManagementAlgorithms,
+		"id_token_encryption_enc_values_supported":         utils.JWEContentEncryptionAlgorithms,
+		"userinfo_signing_alg_values_supported":            service.UserinfoSigningAlgorithms,
+		"userinfo_encryption_alg_values_supported":         utils.JWEKeyManagementAlgorithms,
+		"userinfo_encryption_enc_values_supported":         utils.JWEContentEncryptionAlgorithms,
 	}
 	c.JSON(http.StatusOK, config)
 }
@@ -16,6 +16,10 @@ type AppConfigUpdateDto struct {
 	SessionDuration                    string `json:"sessionDuration" binding:"required"`
 	EmailsVerified                     string `json:"emailsVerified" binding:"required"`
 	AllowOwnAccountEdit                string `json:"allowOwnAccountEdit" binding:"required"`
+	IDTokenLifetime                    string `json:"idTokenLifetime" binding:"required,lifetime=1440"`
+	AccessTokenLifetime                string `json:"accessTokenLifetime" binding:"required,lifetime=1440"`
+	RefreshTokenLifetime               string `json:"refreshTokenLifetime" binding:"required,lifetime=525600"`
+	AuthorizationCodeLifetime          string `json:"authorizationCodeLifetime" binding:"required,lifetime=60"`
 	SmtHost                            string `json:"smtpHost"`
 	SmtpPort                           string `json:"smtpPort"`
 	SmtpFrom                           string `json:"smtpFrom" binding:"omitempty,email"`
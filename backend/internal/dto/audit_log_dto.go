@@ -14,6 +14,6 @@ type AuditLogDto struct {
 	Country   string              `json:"country"`
 	City      string              `json:"city"`
 	Device    string              `json:"device"`
-	UserID    string              `json:"userID"`
+	UserID    *string             `json:"userID"`
 	Data      model.AuditLogData  `json:"data"`
 }
@@ -1,70 +1,462 @@
 package dto
 
+import (
+	"crypto/x509"
+	"encoding/json"
+	"time"
+
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+)
+
 type PublicOidcClientDto struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
 	HasLogo bool   `json:"hasLogo"`
 }
 
+type AuthorizedOidcClientDto struct {
+	Scope            string              `json:"scope"`
+	Client           PublicOidcClientDto `json:"client"`
+	CreatedAt        *datatype.DateTime  `json:"createdAt"`
+	LastAuthorizedAt *datatype.DateTime  `json:"lastAuthorizedAt"`
+}
+
 type OidcClientDto struct {
 	PublicOidcClientDto
-	CallbackURLs       []string `json:"callbackURLs"`
-	LogoutCallbackURLs []string `json:"logoutCallbackURLs"`
-	IsPublic           bool     `json:"isPublic"`
-	PkceEnabled        bool     `json:"pkceEnabled"`
+	CallbackURLs                      []string `json:"callbackURLs"`
+	LogoutCallbackURLs                []string `json:"logoutCallbackURLs"`
+	IsPublic                          bool     `json:"isPublic"`
+	PkceEnabled                       bool     `json:"pkceEnabled"`
+	RequirePar                        bool     `json:"requirePar"`
+	RequireSignedRequest              bool     `json:"requireSignedRequest"`
+	RequestURIs                       []string `json:"requestUris"`
+	RequireDpop                       bool     `json:"requireDpop"`
+	ImplicitFlowEnabled               bool     `json:"implicitFlowEnabled"`
+	Jwks                              string   `json:"jwks"`
+	JwksURI                           string   `json:"jwksUri"`
+	TokenEndpointAuthMethod           string   `json:"tokenEndpointAuthMethod"`
+	TlsClientAuthSubjectDN            string   `json:"tlsClientAuthSubjectDn"`
+	BackchannelLogoutURI              string   `json:"backchannelLogoutUri"`
+	FrontchannelLogoutURI             string   `json:"frontchannelLogoutUri"`
+	FrontchannelLogoutSessionRequired bool     `json:"frontchannelLogoutSessionRequired"`
+	SubjectType                       string   `json:"subjectType"`
+	SectorIdentifierURI               string   `json:"sectorIdentifierUri"`
+	IDTokenSignedResponseAlg          string   `json:"idTokenSignedResponseAlg"`
+	IDTokenEncryptedResponseAlg       string   `json:"idTokenEncryptedResponseAlg"`
+	IDTokenEncryptedResponseEnc       string   `json:"idTokenEncryptedResponseEnc"`
+	UserinfoSignedResponseAlg         string   `json:"userinfoSignedResponseAlg"`
+	UserinfoEncryptedResponseAlg      string   `json:"userinfoEncryptedResponseAlg"`
+	UserinfoEncryptedResponseEnc      string   `json:"userinfoEncryptedResponseEnc"`
+	IDTokenLifetime                   int      `json:"idTokenLifetime"`
+	AccessTokenLifetime               int      `json:"accessTokenLifetime"`
+	RefreshTokenLifetime              int      `json:"refreshTokenLifetime"`
+	AuthorizationCodeLifetime         int      `json:"authorizationCodeLifetime"`
+	ClientCredentialsScopes           []string `json:"clientCredentialsScopes"`
+	TokenExchangeAudiences            []string `json:"tokenExchangeAudiences"`
 }
 
 type OidcClientWithAllowedUserGroupsDto struct {
 	PublicOidcClientDto
-	CallbackURLs       []string                    `json:"callbackURLs"`
-	LogoutCallbackURLs []string                    `json:"logoutCallbackURLs"`
-	IsPublic           bool                        `json:"isPublic"`
-	PkceEnabled        bool                        `json:"pkceEnabled"`
-	AllowedUserGroups  []UserGroupDtoWithUserCount `json:"allowedUserGroups"`
+	CallbackURLs                      []string                    `json:"callbackURLs"`
+	LogoutCallbackURLs                []string                    `json:"logoutCallbackURLs"`
+	IsPublic                          bool                        `json:"isPublic"`
+	PkceEnabled                       bool                        `json:"pkceEnabled"`
+	RequirePar                        bool                        `json:"requirePar"`
+	RequireSignedRequest              bool                        `json:"requireSignedRequest"`
+	RequestURIs                       []string                    `json:"requestUris"`
+	RequireDpop                       bool                        `json:"requireDpop"`
+	ImplicitFlowEnabled               bool                        `json:"implicitFlowEnabled"`
+	Jwks                              string                      `json:"jwks"`
+	JwksURI                           string                      `json:"jwksUri"`
+	TokenEndpointAuthMethod           string                      `json:"tokenEndpointAuthMethod"`
+	TlsClientAuthSubjectDN            string                      `json:"tlsClientAuthSubjectDn"`
+	BackchannelLogoutURI              string                      `json:"backchannelLogoutUri"`
+	FrontchannelLogoutURI             string                      `json:"frontchannelLogoutUri"`
+	FrontchannelLogoutSessionRequired bool                        `json:"frontchannelLogoutSessionRequired"`
+	SubjectType                       string                      `json:"subjectType"`
+	SectorIdentifierURI               string                      `json:"sectorIdentifierUri"`
+	IDTokenSignedResponseAlg          string                      `json:"idTokenSignedResponseAlg"`
+	IDTokenEncryptedResponseAlg       string                      `json:"idTokenEncryptedResponseAlg"`
+	IDTokenEncryptedResponseEnc       string                      `json:"idTokenEncryptedResponseEnc"`
+	UserinfoSignedResponseAlg         string                      `json:"userinfoSignedResponseAlg"`
+	UserinfoEncryptedResponseAlg      string                      `json:"userinfoEncryptedResponseAlg"`
+	UserinfoEncryptedResponseEnc      string                      `json:"userinfoEncryptedResponseEnc"`
+	IDTokenLifetime                   int                         `json:"idTokenLifetime"`
+	AccessTokenLifetime               int                         `json:"accessTokenLifetime"`
+	RefreshTokenLifetime              int                         `json:"refreshTokenLifetime"`
+	AuthorizationCodeLifetime         int                         `json:"authorizationCodeLifetime"`
+	ClientCredentialsScopes           []string                    `json:"clientCredentialsScopes"`
+	TokenExchangeAudiences            []string                    `json:"tokenExchangeAudiences"`
+	AllowedUserGroups                 []UserGroupDtoWithUserCount `json:"allowedUserGroups"`
 }
 
 type OidcClientCreateDto struct {
-	Name               string   `json:"name" binding:"required,max=50"`
-	CallbackURLs       []string `json:"callbackURLs" binding:"required"`
-	LogoutCallbackURLs []string `json:"logoutCallbackURLs"`
-	IsPublic           bool     `json:"isPublic"`
-	PkceEnabled        bool     `json:"pkceEnabled"`
+	Name                              string   `json:"name" binding:"required,max=50"`
+	CallbackURLs                      []string `json:"callbackURLs" binding:"required"`
+	LogoutCallbackURLs                []string `json:"logoutCallbackURLs"`
+	IsPublic                          bool     `json:"isPublic"`
+	PkceEnabled                       bool     `json:"pkceEnabled"`
+	RequirePar                        bool     `json:"requirePar"`
+	RequireSignedRequest              bool     `json:"requireSignedRequest"`
+	RequestURIs                       []string `json:"requestUris"`
+	RequireDpop                       bool     `json:"requireDpop"`
+	ImplicitFlowEnabled               bool     `json:"implicitFlowEnabled"`
+	Jwks                              string   `json:"jwks"`
+	JwksURI                           string   `json:"jwksUri"`
+	TokenEndpointAuthMethod           string   `json:"tokenEndpointAuthMethod"`
+	TlsClientAuthSubjectDN            string   `json:"tlsClientAuthSubjectDn"`
+	BackchannelLogoutURI              string   `json:"backchannelLogoutUri"`
+	FrontchannelLogoutURI             string   `json:"frontchannelLogoutUri"`
+	FrontchannelLogoutSessionRequired bool     `json:"frontchannelLogoutSessionRequired"`
+	SubjectType                       string   `json:"subjectType"`
+	SectorIdentifierURI               string   `json:"sectorIdentifierUri"`
+	IDTokenSignedResponseAlg          string   `json:"idTokenSignedResponseAlg"`
+	IDTokenEncryptedResponseAlg       string   `json:"idTokenEncryptedResponseAlg"`
+	IDTokenEncryptedResponseEnc       string   `json:"idTokenEncryptedResponseEnc"`
+	UserinfoSignedResponseAlg         string   `json:"userinfoSignedResponseAlg"`
+	UserinfoEncryptedResponseAlg      string   `json:"userinfoEncryptedResponseAlg"`
+	UserinfoEncryptedResponseEnc      string   `json:"userinfoEncryptedResponseEnc"`
+	IDTokenLifetime                   int      `json:"idTokenLifetime" binding:"min=0,max=1440"`
+	AccessTokenLifetime               int      `json:"accessTokenLifetime" binding:"min=0,max=1440"`
+	RefreshTokenLifetime              int      `json:"refreshTokenLifetime" binding:"min=0,max=525600"`
+	AuthorizationCodeLifetime         int      `json:"authorizationCodeLifetime" binding:"min=0,max=60"`
+	ClientCredentialsScopes           []string `json:"clientCredentialsScopes"`
+	TokenExchangeAudiences            []string `json:"tokenExchangeAudiences"`
 }
 
 type AuthorizeOidcClientRequestDto struct {
-	ClientID            string `json:"clientID" binding:"required"`
-	Scope               string `json:"scope" binding:"required"`
-	CallbackURL         string `json:"callbackURL"`
-	Nonce               string `json:"nonce"`
-	CodeChallenge       string `json:"codeChallenge"`
-	CodeChallengeMethod string `json:"codeChallengeMethod"`
+	ClientID            string   `json:"clientID" binding:"required"`
+	ResponseType        string   `json:"responseType"`
+	ResponseMode        string   `json:"responseMode"`
+	Scope               string   `json:"scope" binding:"required"`
+	CallbackURL         string   `json:"callbackURL"`
+	State               string   `json:"state"`
+	Nonce               string   `json:"nonce"`
+	CodeChallenge       string   `json:"codeChallenge"`
+	CodeChallengeMethod string   `json:"codeChallengeMethod"`
+	Prompt              string   `json:"prompt"`
+	MaxAge              *int     `json:"maxAge"`
+	LoginHint           string   `json:"loginHint"`
+	Claims              string   `json:"claims"`
+	Resource            []string `json:"resource"`
+	Request             string   `json:"request"`
+	RequestURI          string   `json:"requestUri"`
 }
 
 type AuthorizeOidcClientResponseDto struct {
-	Code        string `json:"code"`
 	CallbackURL string `json:"callbackURL"`
+	// ResponseMode is query, fragment or form_post and defines how the parameters are passed to the callback URL
+	ResponseMode string `json:"responseMode"`
+	// Parameters are the parameters of the authorization response. JWT secured responses only contain the response parameter.
+	Parameters map[string]string `json:"parameters"`
+	// FormPostHTML is the page that posts the parameters to the callback URL if the response mode is form_post
+	FormPostHTML string `json:"formPostHtml,omitempty"`
 }
 
 type AuthorizationRequiredDto struct {
 	ClientID string `json:"clientID" binding:"required"`
 	Scope    string `json:"scope" binding:"required"`
+	Claims   string `json:"claims"`
+}
+
+// OidcClientCredentialsDto contains the credentials a client authenticates itself with at the OAuth endpoints
+type OidcClientCredentialsDto struct {
+	ClientID            string `form:"client_id"`
+	ClientSecret        string `form:"client_secret"`
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+
+	// ClientCertificate is the certificate the client presented over mutual TLS
+	ClientCertificate *x509.Certificate `form:"-"`
 }
 
 type OidcCreateTokensDto struct {
+	OidcClientCredentialsDto
 	GrantType    string `form:"grant_type" binding:"required"`
-	Code         string `form:"code" binding:"required"`
-	ClientID     string `form:"client_id"`
-	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
 	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	DeviceCode   string `form:"device_code"`
+	Scope        string `form:"scope"`
+	// Resource narrows the audience of the access token down to some of the granted API resources
+	Resource []string `form:"resource"`
+
+	// Token exchange parameters as defined by RFC 8693
+	SubjectToken       string `form:"subject_token"`
+	SubjectTokenType   string `form:"subject_token_type"`
+	ActorToken         string `form:"actor_token"`
+	RequestedTokenType string `form:"requested_token_type"`
+	Audience           string `form:"audience"`
+
+	Dpop OidcDpopProofDto `form:"-"`
+}
+
+// OidcDpopProofDto contains the DPoP proof of a request and the request it has to match
+type OidcDpopProofDto struct {
+	Proof      string
+	HttpMethod string
+	HttpURL    string
+}
+
+// OidcAccessTokenRequestDto contains the parts of a request that are needed to verify a sender-constrained access token
+type OidcAccessTokenRequestDto struct {
+	AccessToken         string
+	AuthorizationScheme string
+	ClientCertificate   *x509.Certificate
+	Dpop                OidcDpopProofDto
+}
+
+type OidcTokenResponseDto struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	IdToken      string `json:"id_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
 
 type OidcUpdateAllowedUserGroupsDto struct {
 	UserGroupIDs []string `json:"userGroupIds" binding:"required"`
 }
 
+type OidcLogoutResponseDto struct {
+	FrontchannelLogoutURLs []string `json:"frontchannelLogoutUrls"`
+}
+
 type OidcLogoutDto struct {
 	IdTokenHint           string `form:"id_token_hint"`
 	ClientId              string `form:"client_id"`
 	PostLogoutRedirectUri string `form:"post_logout_redirect_uri"`
 	State                 string `form:"state"`
 }
+
+type OidcDeviceAuthorizationRequestDto struct {
+	OidcClientCredentialsDto
+	Scope string `form:"scope"`
+}
+
+type OidcDeviceAuthorizationResponseDto struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type OidcDeviceCodeInfoDto struct {
+	Client                PublicOidcClientDto `json:"client"`
+	Scope                 string              `json:"scope"`
+	AuthorizationRequired bool                `json:"authorizationRequired"`
+}
+
+type OidcVerifyDeviceCodeDto struct {
+	UserCode string `json:"userCode" binding:"required"`
+}
+
+type OidcIntrospectDto struct {
+	OidcClientCredentialsDto
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+type OidcIntrospectionResponseDto struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+
+	Cnf *OidcTokenConfirmationDto `json:"cnf,omitempty"`
+	Act *OidcTokenActorDto        `json:"act,omitempty"`
+}
+
+// OidcTokenActorDto is the party that acts on behalf of the subject of an exchanged token.
+// Previous actors of a delegation chain are nested.
+type OidcTokenActorDto struct {
+	Sub string             `json:"sub"`
+	Act *OidcTokenActorDto `json:"act,omitempty"`
+}
+
+// OidcAccessTokenUserDto contains the claims about the user an access token carries in addition to the subject as defined by RFC 9068
+type OidcAccessTokenUserDto struct {
+	AuthTime *time.Time
+	Groups   []string
+	Roles    interface{}
+}
+
+// OidcTokenConfirmationDto contains the key an access token is bound to
+type OidcTokenConfirmationDto struct {
+	X5tS256 string `json:"x5t#S256,omitempty"`
+	Jkt     string `json:"jkt,omitempty"`
+}
+
+type OidcRevokeTokenDto struct {
+	OidcClientCredentialsDto
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+type OidcInitialAccessTokenCreateDto struct {
+	Description string    `json:"description" binding:"max=100"`
+	ExpiresAt   time.Time `json:"expiresAt" binding:"required"`
+}
+
+type OidcInitialAccessTokenDto struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	ExpiresAt   datatype.DateTime `json:"expiresAt"`
+	CreatedAt   datatype.DateTime `json:"createdAt"`
+}
+
+type OidcInitialAccessTokenWithTokenDto struct {
+	OidcInitialAccessTokenDto
+	Token string `json:"token"`
+}
+
+type OidcResourceDto struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Identifier     string                `json:"identifier"`
+	Scopes         []string              `json:"scopes"`
+	AllowedClients []PublicOidcClientDto `json:"allowedClients"`
+	CreatedAt      datatype.DateTime     `json:"createdAt"`
+}
+
+type OidcResourceCreateDto struct {
+	Name       string   `json:"name" binding:"required,max=50"`
+	Identifier string   `json:"identifier" binding:"required,max=255"`
+	Scopes     []string `json:"scopes"`
+}
+
+type OidcResourceUpdateAllowedClientsDto struct {
+	ClientIDs []string `json:"clientIds" binding:"required"`
+}
+
+type OidcScopeDto struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Claims      []string          `json:"claims"`
+	CreatedAt   datatype.DateTime `json:"createdAt"`
+}
+
+type OidcScopeCreateDto struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description" binding:"max=100"`
+	Claims      []string `json:"claims"`
+}
+
+// OidcClientRegistrationDto contains the client metadata defined by RFC 7591
+type OidcClientRegistrationDto struct {
+	ClientID                          string          `json:"client_id"`
+	ClientName                        string          `json:"client_name"`
+	RedirectURIs                      []string        `json:"redirect_uris"`
+	PostLogoutRedirectURIs            []string        `json:"post_logout_redirect_uris"`
+	TokenEndpointAuthMethod           string          `json:"token_endpoint_auth_method"`
+	TlsClientAuthSubjectDN            string          `json:"tls_client_auth_subject_dn,omitempty"`
+	BackchannelLogoutURI              string          `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI             string          `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool            `json:"frontchannel_logout_session_required"`
+	SubjectType                       string          `json:"subject_type"`
+	SectorIdentifierURI               string          `json:"sector_identifier_uri,omitempty"`
+	IDTokenSignedResponseAlg          string          `json:"id_token_signed_response_alg,omitempty"`
+	IDTokenEncryptedResponseAlg       string          `json:"id_token_encrypted_response_alg,omitempty"`
+	IDTokenEncryptedResponseEnc       string          `json:"id_token_encrypted_response_enc,omitempty"`
+	UserinfoSignedResponseAlg         string          `json:"userinfo_signed_response_alg,omitempty"`
+	UserinfoEncryptedResponseAlg      string          `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserinfoEncryptedResponseEnc      string          `json:"userinfo_encrypted_response_enc,omitempty"`
+	LogoURI                           string          `json:"logo_uri"`
+	RequirePar                        bool            `json:"require_pushed_authorization_requests"`
+	RequireSignedRequest              bool            `json:"require_signed_request_object"`
+	RequestURIs                       []string        `json:"request_uris"`
+	RequireDpop                       bool            `json:"dpop_bound_access_tokens"`
+	ResponseTypes                     []string        `json:"response_types"`
+	Jwks                              json.RawMessage `json:"jwks"`
+	JwksURI                           string          `json:"jwks_uri"`
+}
+
+type OidcClientRegistrationResponseDto struct {
+	ClientID                          string          `json:"client_id"`
+	ClientSecret                      string          `json:"client_secret,omitempty"`
+	ClientIDIssuedAt                  int64           `json:"client_id_issued_at"`
+	ClientSecretExpiresAt             int64           `json:"client_secret_expires_at"`
+	RegistrationAccessToken           string          `json:"registration_access_token,omitempty"`
+	RegistrationClientURI             string          `json:"registration_client_uri"`
+	ClientName                        string          `json:"client_name"`
+	RedirectURIs                      []string        `json:"redirect_uris"`
+	PostLogoutRedirectURIs            []string        `json:"post_logout_redirect_uris,omitempty"`
+	TokenEndpointAuthMethod           string          `json:"token_endpoint_auth_method"`
+	TlsClientAuthSubjectDN            string          `json:"tls_client_auth_subject_dn,omitempty"`
+	BackchannelLogoutURI              string          `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI             string          `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool            `json:"frontchannel_logout_session_required"`
+	SubjectType                       string          `json:"subject_type"`
+	SectorIdentifierURI               string          `json:"sector_identifier_uri,omitempty"`
+	IDTokenSignedResponseAlg          string          `json:"id_token_signed_response_alg,omitempty"`
+	IDTokenEncryptedResponseAlg       string          `json:"id_token_encrypted_response_alg,omitempty"`
+	IDTokenEncryptedResponseEnc       string          `json:"id_token_encrypted_response_enc,omitempty"`
+	UserinfoSignedResponseAlg         string          `json:"userinfo_signed_response_alg,omitempty"`
+	UserinfoEncryptedResponseAlg      string          `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserinfoEncryptedResponseEnc      string          `json:"userinfo_encrypted_response_enc,omitempty"`
+	LogoURI                           string          `json:"logo_uri,omitempty"`
+	RequirePar                        bool            `json:"require_pushed_authorization_requests"`
+	RequireSignedRequest              bool            `json:"require_signed_request_object"`
+	RequestURIs                       []string        `json:"request_uris,omitempty"`
+	RequireDpop                       bool            `json:"dpop_bound_access_tokens"`
+	ResponseTypes                     []string        `json:"response_types"`
+	Jwks                              json.RawMessage `json:"jwks,omitempty"`
+	JwksURI                           string          `json:"jwks_uri,omitempty"`
+}
+
+// OidcAuthorizationRequestDto contains the parameters of an authorization request as sent by the client
+type OidcAuthorizationRequestDto struct {
+	ClientID            string   `form:"client_id"`
+	ResponseType        string   `form:"response_type"`
+	ResponseMode        string   `form:"response_mode"`
+	Scope               string   `form:"scope"`
+	RedirectURI         string   `form:"redirect_uri"`
+	State               string   `form:"state"`
+	Nonce               string   `form:"nonce"`
+	CodeChallenge       string   `form:"code_challenge"`
+	CodeChallengeMethod string   `form:"code_challenge_method"`
+	Prompt              string   `form:"prompt"`
+	MaxAge              *int     `form:"max_age"`
+	LoginHint           string   `form:"login_hint"`
+	Claims              string   `form:"claims"`
+	Resource            []string `form:"resource"`
+	Request             string   `form:"request"`
+	RequestURI          string   `form:"request_uri"`
+}
+
+type OidcPushedAuthorizationRequestDto struct {
+	OidcAuthorizationRequestDto
+	OidcClientCredentialsDto
+}
+
+type OidcPushedAuthorizationResponseDto struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// OidcAuthorizationRequestParametersDto contains the resolved parameters the authorization page needs
+type OidcAuthorizationRequestParametersDto struct {
+	ResponseType        string `json:"responseType"`
+	ResponseMode        string `json:"responseMode"`
+	Scope               string `json:"scope"`
+	CallbackURL         string `json:"callbackURL"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+	Prompt              string `json:"prompt"`
+	MaxAge              *int   `json:"maxAge"`
+	LoginHint           string `json:"loginHint"`
+	// Claims is only returned so that the user can consent to them, the claims are read from the request when the client is authorized
+	Claims string `json:"claims"`
+}
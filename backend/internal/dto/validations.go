@@ -5,6 +5,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"log"
 	"regexp"
+	"strconv"
 )
 
 var validateUsername validator.Func = func(fl validator.FieldLevel) bool {
@@ -23,6 +24,19 @@ var validateClaimKey validator.Func = func(fl validator.FieldLevel) bool {
 	return matched
 }
 
+var validateLifetime validator.Func = func(fl validator.FieldLevel) bool {
+	// The string must be a number of minutes between 1 and the maximum passed as parameter
+	lifetime, err := strconv.Atoi(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	maxLifetime, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+	return lifetime >= 1 && lifetime <= maxLifetime
+}
+
 func init() {
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		if err := v.RegisterValidation("username", validateUsername); err != nil {
@@ -34,4 +48,9 @@ func init() {
 			log.Fatalf("Failed to register custom validation: %v", err)
 		}
 	}
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := v.RegisterValidation("lifetime", validateLifetime); err != nil {
+			log.Fatalf("Failed to register custom validation: %v", err)
+		}
+	}
 }
@@ -22,6 +22,13 @@ func RegisterDbCleanupJobs(db *gorm.DB) {
 	registerJob(scheduler, "ClearWebauthnSessions", "0 3 * * *", jobs.clearWebauthnSessions)
 	registerJob(scheduler, "ClearOneTimeAccessTokens", "0 3 * * *", jobs.clearOneTimeAccessTokens)
 	registerJob(scheduler, "ClearOidcAuthorizationCodes", "0 3 * * *", jobs.clearOidcAuthorizationCodes)
+	registerJob(scheduler, "ClearOidcRefreshTokens", "0 3 * * *", jobs.clearOidcRefreshTokens)
+	registerJob(scheduler, "ClearOidcDeviceCodes", "0 3 * * *", jobs.clearOidcDeviceCodes)
+	registerJob(scheduler, "ClearOidcRevokedTokens", "0 3 * * *", jobs.clearOidcRevokedTokens)
+	registerJob(scheduler, "ClearOidcInitialAccessTokens", "0 3 * * *", jobs.clearOidcInitialAccessTokens)
+	registerJob(scheduler, "ClearOidcPushedAuthorizationRequests", "0 3 * * *", jobs.clearOidcPushedAuthorizationRequests)
+	registerJob(scheduler, "ClearOidcClientAssertions", "0 3 * * *", jobs.clearOidcClientAssertions)
+	registerJob(scheduler, "ClearOidcDpopProofs", "0 3 * * *", jobs.clearOidcDpopProofs)
 	scheduler.Start()
 }
 
@@ -44,6 +51,41 @@ func (j *Jobs) clearOidcAuthorizationCodes() error {
 	return j.db.Delete(&model.OidcAuthorizationCode{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
 }
 
+// ClearOidcRefreshTokens deletes OIDC refresh tokens that have expired
+func (j *Jobs) clearOidcRefreshTokens() error {
+	return j.db.Delete(&model.OidcRefreshToken{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcDeviceCodes deletes OIDC device codes that have expired
+func (j *Jobs) clearOidcDeviceCodes() error {
+	return j.db.Delete(&model.OidcDeviceCode{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcRevokedTokens deletes revoked OIDC access tokens that have expired anyway
+func (j *Jobs) clearOidcRevokedTokens() error {
+	return j.db.Delete(&model.OidcRevokedToken{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcInitialAccessTokens deletes initial access tokens for dynamic client registration that have expired
+func (j *Jobs) clearOidcInitialAccessTokens() error {
+	return j.db.Delete(&model.OidcInitialAccessToken{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcPushedAuthorizationRequests deletes pushed authorization requests that have expired
+func (j *Jobs) clearOidcPushedAuthorizationRequests() error {
+	return j.db.Delete(&model.OidcPushedAuthorizationRequest{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcClientAssertions deletes used client assertions that have expired
+func (j *Jobs) clearOidcClientAssertions() error {
+	return j.db.Delete(&model.OidcClientAssertion{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
+// ClearOidcDpopProofs deletes used DPoP proofs that have expired
+func (j *Jobs) clearOidcDpopProofs() error {
+	return j.db.Delete(&model.OidcDpopProof{}, "expires_at < ?", datatype.DateTime(time.Now())).Error
+}
+
 // ClearAuditLogs deletes audit logs older than 90 days
 func (j *Jobs) clearAuditLogs() error {
 	return j.db.Delete(&model.AuditLog{}, "created_at < ?", datatype.DateTime(time.Now().AddDate(0, 0, -90))).Error
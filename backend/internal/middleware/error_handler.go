@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -48,6 +49,13 @@ func (m *ErrorHandlerMiddleware) Add() gin.HandlerFunc {
 				}
 			}
 
+			// OAuth clients expect the error code of the specification
+			var oauthErr common.OAuthError
+			if errors.As(err, &oauthErr) {
+				c.JSON(oauthErr.HttpStatusCode(), gin.H{"error": oauthErr.OAuthErrorCode(), "error_description": oauthErr.Error()})
+				return
+			}
+
 			var appErr common.AppError
 			if errors.As(err, &appErr) {
 				errorResponse(c, appErr.HttpStatusCode(), appErr.Error())
@@ -81,9 +89,17 @@ func handleValidationError(validationErrors validator.ValidationErrors) string {
 		case "url":
 			errorMessage = fmt.Sprintf("%s must be a valid URL", fieldName)
 		case "min":
-			errorMessage = fmt.Sprintf("%s must be at least %s characters long", fieldName, ve.Param())
+			if isNumberKind(ve.Kind()) {
+				errorMessage = fmt.Sprintf("%s must be at least %s", fieldName, ve.Param())
+			} else {
+				errorMessage = fmt.Sprintf("%s must be at least %s characters long", fieldName, ve.Param())
+			}
 		case "max":
-			errorMessage = fmt.Sprintf("%s must be at most %s characters long", fieldName, ve.Param())
+			if isNumberKind(ve.Kind()) {
+				errorMessage = fmt.Sprintf("%s must be at most %s", fieldName, ve.Param())
+			} else {
+				errorMessage = fmt.Sprintf("%s must be at most %s characters long", fieldName, ve.Param())
+			}
 		default:
 			errorMessage = fmt.Sprintf("%s is invalid", fieldName)
 		}
@@ -96,3 +112,7 @@ func handleValidationError(validationErrors validator.ValidationErrors) string {
 
 	return combinedErrors
 }
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
@@ -55,6 +55,17 @@ func (m *JwtAuthMiddleware) Add(adminOnly bool) gin.HandlerFunc {
 
 		c.Set("userID", claims.Subject)
 		c.Set("userIsAdmin", claims.IsAdmin)
+		c.Set("loginSessionID", claims.ID)
+
+		// Tokens issued before the authentication time was recorded were created when the user authenticated
+		authTime := claims.IssuedAt
+		if claims.AuthTime != nil {
+			authTime = claims.AuthTime
+		}
+		if authTime != nil {
+			c.Set("authTime", authTime.Time)
+		}
+		c.Set("amr", claims.Amr)
 		c.Next()
 	}
 }
@@ -15,6 +15,11 @@ type AppConfig struct {
 	SessionDuration     AppConfigVariable
 	EmailsVerified      AppConfigVariable
 	AllowOwnAccountEdit AppConfigVariable
+	// OIDC
+	IDTokenLifetime           AppConfigVariable
+	AccessTokenLifetime       AppConfigVariable
+	RefreshTokenLifetime      AppConfigVariable
+	AuthorizationCodeLifetime AppConfigVariable
 	// Internal
 	BackgroundImageType AppConfigVariable
 	LogoLightImageType  AppConfigVariable
@@ -14,7 +14,7 @@ type AuditLog struct {
 	Country   string        `sortable:"true"`
 	City      string        `sortable:"true"`
 	UserAgent string        `sortable:"true"`
-	UserID    string
+	UserID    *string
 	Data      AuditLogData
 }
 
@@ -23,10 +23,15 @@ type AuditLogData map[string]string
 type AuditLogEvent string
 
 const (
-	AuditLogEventSignIn                   AuditLogEvent = "SIGN_IN"
-	AuditLogEventOneTimeAccessTokenSignIn AuditLogEvent = "TOKEN_SIGN_IN"
-	AuditLogEventClientAuthorization      AuditLogEvent = "CLIENT_AUTHORIZATION"
-	AuditLogEventNewClientAuthorization   AuditLogEvent = "NEW_CLIENT_AUTHORIZATION"
+	AuditLogEventSignIn                     AuditLogEvent = "SIGN_IN"
+	AuditLogEventOneTimeAccessTokenSignIn   AuditLogEvent = "TOKEN_SIGN_IN"
+	AuditLogEventClientAuthorization        AuditLogEvent = "CLIENT_AUTHORIZATION"
+	AuditLogEventNewClientAuthorization     AuditLogEvent = "NEW_CLIENT_AUTHORIZATION"
+	AuditLogEventClientAuthorizationRevoked AuditLogEvent = "CLIENT_AUTHORIZATION_REVOKED"
+	AuditLogEventClientCredentialsGrant     AuditLogEvent = "CLIENT_CREDENTIALS_GRANT"
+	AuditLogEventTokenExchange              AuditLogEvent = "TOKEN_EXCHANGE"
+	AuditLogEventBackchannelLogout          AuditLogEvent = "BACKCHANNEL_LOGOUT"
+	AuditLogEventBackchannelLogoutFailed    AuditLogEvent = "BACKCHANNEL_LOGOUT_FAILED"
 )
 
 // Scan and Value methods for GORM to handle the custom type
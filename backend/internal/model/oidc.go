@@ -10,12 +10,123 @@ import (
 )
 
 type UserAuthorizedOidcClient struct {
-	Scope  string
+	Scope string
+	// Claims is the claims request parameter of the last authorization as JSON
+	Claims string
+	// ConsentedClaims are the claims the user allowed the client to request individually with the claims request parameter
+	ConsentedClaims StringList
+
 	UserID string `gorm:"primary_key;"`
 	User   User
 
 	ClientID string `gorm:"primary_key;"`
 	Client   OidcClient
+
+	// CreatedAt and LastAuthorizedAt are nil for authorizations that were stored before they were recorded
+	CreatedAt        *datatype.DateTime
+	LastAuthorizedAt *datatype.DateTime
+}
+
+// OidcRevokedToken is an access token that was revoked before it expired. The ID is the "jti" claim of the token.
+type OidcRevokedToken struct {
+	Base
+
+	ExpiresAt datatype.DateTime
+}
+
+// OidcInitialAccessToken is minted by an admin and allows to register clients dynamically until it expires
+type OidcInitialAccessToken struct {
+	Base
+
+	Token       string
+	Description string
+	ExpiresAt   datatype.DateTime
+
+	CreatedByID string
+	CreatedBy   User
+}
+
+// OidcPushedAuthorizationRequest contains the authorization parameters a client pushed before redirecting the user
+type OidcPushedAuthorizationRequest struct {
+	Base
+
+	RequestURI          string
+	ResponseType        string
+	ResponseMode        string
+	Scope               string
+	CallbackURL         string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Prompt              string
+	MaxAge              *int
+	LoginHint           string
+	Claims              string
+	Resources           StringList
+	ExpiresAt           datatype.DateTime
+
+	ClientID string
+}
+
+// OidcClientAssertion is a client assertion that was already used. It is stored to prevent replay attacks.
+type OidcClientAssertion struct {
+	Base
+
+	Jti       string
+	ExpiresAt datatype.DateTime
+
+	ClientID string
+}
+
+// OidcDpopProof is a DPoP proof that was already used. It is stored to prevent replay attacks.
+type OidcDpopProof struct {
+	Base
+
+	Jti       string
+	ExpiresAt datatype.DateTime
+}
+
+// OidcSession is the session of a user at a client. Its ID is the sid claim of the ID tokens and the logout tokens.
+type OidcSession struct {
+	Base
+
+	UserID   string
+	ClientID string
+	Client   OidcClient
+	// LoginSessionID is the ID of the Pocket ID session the user signed in to the client with
+	LoginSessionID string
+
+	// AuthTime and Amr describe the authentication of the user the client was last authorized with
+	AuthTime *datatype.DateTime
+	Amr      StringList
+}
+
+// OidcPairwiseSubject maps a pairwise subject identifier back to the user it was derived for
+type OidcPairwiseSubject struct {
+	Subject          string `gorm:"primaryKey"`
+	SectorIdentifier string
+	UserID           string
+}
+
+type OidcRefreshToken struct {
+	Base
+
+	Token     string
+	FamilyID  string
+	Scope     string
+	Resources StringList
+	Used      bool
+	ExpiresAt datatype.DateTime
+	// DpopJkt is the thumbprint of the DPoP key the refresh token of a public client is bound to
+	DpopJkt string
+	// SessionID is the session the refresh token was issued in. It is empty for tokens issued without a session.
+	SessionID string
+
+	UserID string
+	User   User
+
+	ClientID string
 }
 
 type OidcAuthorizationCode struct {
@@ -23,10 +134,13 @@ type OidcAuthorizationCode struct {
 
 	Code                      string
 	Scope                     string
+	Resources                 StringList
 	Nonce                     string
 	CodeChallenge             *string
 	CodeChallengeMethodSha256 *bool
 	ExpiresAt                 datatype.DateTime
+	// SessionID is the session of the user at the client the code was issued in
+	SessionID string
 
 	UserID string
 	User   User
@@ -34,6 +148,47 @@ type OidcAuthorizationCode struct {
 	ClientID string
 }
 
+type OidcDeviceCode struct {
+	Base
+
+	DeviceCode   string
+	UserCode     string
+	Scope        string
+	IsAuthorized bool
+	LastPolledAt *datatype.DateTime
+	ExpiresAt    datatype.DateTime
+	// PollingInterval is how many seconds the client has to wait between polls. It grows every time the client polls too fast.
+	PollingInterval int
+
+	UserID *string
+	User   User
+
+	ClientID string
+	Client   OidcClient
+}
+
+// OidcResource is an API that accepts access tokens. Its identifier is the audience of the tokens issued for it.
+type OidcResource struct {
+	Base
+
+	Name       string `sortable:"true"`
+	Identifier string `sortable:"true"`
+	// Scopes are the scopes of the resource. Requesting one of them implies the resource as audience.
+	Scopes StringList
+
+	AllowedClients []OidcClient `gorm:"many2many:oidc_resources_allowed_clients;"`
+}
+
+// OidcScope is a scope defined by an admin. It releases the listed custom claims or user attributes.
+type OidcScope struct {
+	Base
+
+	Name string `sortable:"true"`
+	// Description is shown to the user when the client asks for consent
+	Description string
+	Claims      StringList
+}
+
 type OidcClient struct {
 	Base
 
@@ -45,6 +200,68 @@ type OidcClient struct {
 	HasLogo            bool `gorm:"-"`
 	IsPublic           bool
 	PkceEnabled        bool
+	RequirePar         bool
+
+	// RequireSignedRequest requires the authorization parameters to be passed in a request object signed with one of the client keys
+	RequireSignedRequest bool
+	// RequestURIs are the URLs the client can pass request objects by reference from
+	RequestURIs UrlList
+	// ImplicitFlowEnabled allows the client to use the implicit and hybrid response types in addition to code
+	ImplicitFlowEnabled bool
+	// RequireDpop requires access tokens to be bound to a DPoP key as defined by RFC 9449
+	RequireDpop bool
+	// Jwks is the JSON Web Key Set of the client. Alternatively the keys can be fetched from JwksURI.
+	Jwks    string
+	JwksURI string
+
+	// TokenEndpointAuthMethod is the method the client has to use to authenticate itself at the OAuth endpoints
+	TokenEndpointAuthMethod string
+	// TlsClientAuthSubjectDN is the subject of the certificate a client that uses tls_client_auth has to present
+	TlsClientAuthSubjectDN string
+	// JwtSecret is the plain client secret. It is only stored for clients that use client_secret_jwt
+	// because the HMAC signature of their assertions can't be verified with the hashed secret.
+	JwtSecret string
+
+	// ClientCredentialsScopes are the scopes a confidential client can request with the client credentials grant
+	ClientCredentialsScopes StringList
+	// TokenExchangeAudiences are the IDs of the clients a confidential client can exchange access tokens for
+	TokenExchangeAudiences StringList
+
+	// BackchannelLogoutURI is the URL logout tokens are posted to when the user logs out
+	BackchannelLogoutURI string
+	// FrontchannelLogoutURI is the URL that is loaded in an iframe when the user logs out
+	FrontchannelLogoutURI string
+	// FrontchannelLogoutSessionRequired adds the iss and sid query parameters to the front-channel logout URI
+	FrontchannelLogoutSessionRequired bool
+
+	// SubjectType is public if the user ID is the subject or pairwise if the subject is derived from the sector identifier
+	SubjectType string
+	// SectorIdentifierURI is the URL of a JSON array with the callback URLs of the clients that share the pairwise subjects.
+	// Without it, the host of the callback URLs is the sector identifier.
+	SectorIdentifierURI string
+
+	// IDTokenEncryptedResponseAlg and IDTokenEncryptedResponseEnc encrypt the ID tokens to a key of the client if set
+	IDTokenEncryptedResponseAlg string
+	IDTokenEncryptedResponseEnc string
+	// IDTokenSignedResponseAlg is the algorithm the ID tokens are signed with. They are signed with RS256 if it isn't set.
+	IDTokenSignedResponseAlg string
+	// UserinfoSignedResponseAlg returns the userinfo as a signed JWT instead of JSON if set
+	UserinfoSignedResponseAlg string
+	// UserinfoEncryptedResponseAlg and UserinfoEncryptedResponseEnc encrypt the userinfo to a key of the client if set
+	UserinfoEncryptedResponseAlg string
+	UserinfoEncryptedResponseEnc string
+
+	// IDTokenLifetime, AccessTokenLifetime, RefreshTokenLifetime and AuthorizationCodeLifetime are in minutes.
+	// If they are 0, the instance-wide defaults of the app config are used.
+	IDTokenLifetime           int
+	AccessTokenLifetime       int
+	RefreshTokenLifetime      int
+	AuthorizationCodeLifetime int
+
+	// LogoURI is the logo URL of a dynamically registered client. The logo is downloaded and stored like an uploaded logo.
+	LogoURI *string
+	// RegistrationAccessToken is the hashed token a dynamically registered client uses to manage its registration
+	RegistrationAccessToken *string
 
 	AllowedUserGroups []UserGroup `gorm:"many2many:oidc_clients_allowed_user_groups;"`
 	CreatedByID       string
@@ -70,3 +287,17 @@ func (cu *UrlList) Scan(value interface{}) error {
 func (cu UrlList) Value() (driver.Value, error) {
 	return json.Marshal(cu)
 }
+
+type StringList []string
+
+func (sl *StringList) Scan(value interface{}) error {
+	if v, ok := value.([]byte); ok {
+		return json.Unmarshal(v, sl)
+	} else {
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+func (sl StringList) Value() (driver.Value, error) {
+	return json.Marshal(sl)
+}
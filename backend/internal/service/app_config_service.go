@@ -54,6 +54,27 @@ var defaultDbConfig = model.AppConfig{
 		IsPublic:     true,
 		DefaultValue: "true",
 	},
+	// OIDC
+	IDTokenLifetime: model.AppConfigVariable{
+		Key:          "idTokenLifetime",
+		Type:         "number",
+		DefaultValue: "60",
+	},
+	AccessTokenLifetime: model.AppConfigVariable{
+		Key:          "accessTokenLifetime",
+		Type:         "number",
+		DefaultValue: "60",
+	},
+	RefreshTokenLifetime: model.AppConfigVariable{
+		Key:          "refreshTokenLifetime",
+		Type:         "number",
+		DefaultValue: "43200",
+	},
+	AuthorizationCodeLifetime: model.AppConfigVariable{
+		Key:          "authorizationCodeLifetime",
+		Type:         "number",
+		DefaultValue: "15",
+	},
 	// Internal
 	BackgroundImageType: model.AppConfigVariable{
 		Key:          "backgroundImageType",
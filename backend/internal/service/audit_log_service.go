@@ -21,7 +21,7 @@ func NewAuditLogService(db *gorm.DB, appConfigService *AppConfigService, emailSe
 	return &AuditLogService{db: db, appConfigService: appConfigService, emailService: emailService, geoliteService: geoliteService}
 }
 
-// Create creates a new audit log entry in the database
+// Create creates a new audit log entry in the database. The user ID can be empty if the event isn't caused by a user.
 func (s *AuditLogService) Create(event model.AuditLogEvent, ipAddress, userAgent, userID string, data model.AuditLogData) model.AuditLog {
 	country, city, err := s.geoliteService.GetLocationByIP(ipAddress)
 	if err != nil {
@@ -34,10 +34,13 @@ func (s *AuditLogService) Create(event model.AuditLogEvent, ipAddress, userAgent
 		Country:   country,
 		City:      city,
 		UserAgent: userAgent,
-		UserID:    userID,
 		Data:      data,
 	}
 
+	if userID != "" {
+		auditLog.UserID = &userID
+	}
+
 	// Save the audit log in the database
 	if err := s.db.Create(&auditLog).Error; err != nil {
 		log.Printf("Failed to create audit log: %v\n", err)
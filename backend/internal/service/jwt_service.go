@@ -14,16 +14,22 @@ import (
 	"path/filepath"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
 	"github.com/pocket-id/pocket-id/backend/internal/model"
 )
 
 const (
 	privateKeyPath = "data/keys/jwt_private_key.pem"
 	publicKeyPath  = "data/keys/jwt_public_key.pem"
+
+	authorizationResponseDuration = 10 * time.Minute
+	logoutTokenDuration           = 2 * time.Minute
 )
 
 type JwtService struct {
@@ -48,13 +54,49 @@ func NewJwtService(appConfigService *AppConfigService) *JwtService {
 type AccessTokenJWTClaims struct {
 	jwt.RegisteredClaims
 	IsAdmin bool `json:"isAdmin,omitempty"`
+	// AuthTime and Amr describe when and how the user authenticated. They are passed on to the ID tokens.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	Amr      []string         `json:"amr,omitempty"`
+}
+
+type OauthAccessTokenJWTClaims struct {
+	jwt.RegisteredClaims
+	// ClientID is the client the token was issued to. The audience can differ if the token was issued for API resources.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// AuthTime, Groups and Roles describe the user as defined by RFC 9068. They are not set for the client credentials grant.
+	AuthTime *jwt.NumericDate              `json:"auth_time,omitempty"`
+	Groups   []string                      `json:"groups,omitempty"`
+	Roles    interface{}                   `json:"roles,omitempty"`
+	Cnf      *dto.OidcTokenConfirmationDto `json:"cnf,omitempty"`
+	Act      *dto.OidcTokenActorDto        `json:"act,omitempty"`
+}
+
+// oauthAccessTokenType is the typ header of access tokens as defined by RFC 9068.
+// It prevents that other tokens signed with the same key, like ID tokens, are accepted as access tokens.
+const oauthAccessTokenType = "at+jwt"
+
+// sessionTokenType is the typ header of the session tokens of Pocket ID.
+// Only tokens with this type are accepted as sessions, so access tokens can't be used to sign in.
+const sessionTokenType = "JWT"
+
+// GetClientID returns the client the token was issued to.
+// Tokens without the client_id claim were always issued with the client as audience.
+func (c *OauthAccessTokenJWTClaims) GetClientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	if len(c.Audience) > 0 {
+		return c.Audience[0]
+	}
+	return ""
 }
 
 type JWK struct {
 	Kid string `json:"kid"`
 	Kty string `json:"kty"`
 	Use string `json:"use"`
-	Alg string `json:"alg"`
+	Alg string `json:"alg,omitempty"`
 	N   string `json:"n"`
 	E   string `json:"e"`
 }
@@ -88,16 +130,22 @@ func (s *JwtService) loadOrGenerateKeys() error {
 	return nil
 }
 
-func (s *JwtService) GenerateAccessToken(user model.User) (string, error) {
+// GenerateAccessToken generates the session token of the user. The authentication methods are
+// the amr values defined by RFC 8176, e.g. hwk for a passkey. The token ID identifies the login session,
+// so that logging out only ends the sessions at the clients the user signed in to during it.
+func (s *JwtService) GenerateAccessToken(user model.User, authenticationMethods []string) (string, error) {
 	sessionDurationInMinutes, _ := strconv.Atoi(s.appConfigService.DbConfig.SessionDuration.Value)
 	claim := AccessTokenJWTClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Subject:   user.ID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(sessionDurationInMinutes) * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Audience:  jwt.ClaimStrings{common.EnvConfig.AppURL},
 		},
-		IsAdmin: user.IsAdmin,
+		IsAdmin:  user.IsAdmin,
+		AuthTime: jwt.NewNumericDate(time.Now()),
+		Amr:      authenticationMethods,
 	}
 
 	kid, err := s.generateKeyID(s.PublicKey)
@@ -107,6 +155,7 @@ func (s *JwtService) GenerateAccessToken(user model.User) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
 	token.Header["kid"] = kid
+	token.Header["typ"] = sessionTokenType
 
 	return token.SignedString(s.PrivateKey)
 }
@@ -119,6 +168,11 @@ func (s *JwtService) VerifyAccessToken(tokenString string) (*AccessTokenJWTClaim
 		return nil, errors.New("couldn't handle this token")
 	}
 
+	// Access tokens issued to clients are signed with the same key and can have the app URL as audience
+	if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, sessionTokenType) {
+		return nil, errors.New("token is not a session token")
+	}
+
 	claims, isValid := token.Claims.(*AccessTokenJWTClaims)
 	if !isValid {
 		return nil, errors.New("can't parse claims")
@@ -130,10 +184,11 @@ func (s *JwtService) VerifyAccessToken(tokenString string) (*AccessTokenJWTClaim
 	return claims, nil
 }
 
-func (s *JwtService) GenerateIDToken(userClaims map[string]interface{}, clientID string, nonce string) (string, error) {
+// GenerateIDToken signs the ID token with the algorithm the client registered, which must be one of IDTokenSigningAlgorithms
+func (s *JwtService) GenerateIDToken(userClaims map[string]interface{}, clientID string, nonce string, alg string, lifetime time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"aud": clientID,
-		"exp": jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+		"exp": jwt.NewNumericDate(time.Now().Add(lifetime)),
 		"iat": jwt.NewNumericDate(time.Now()),
 		"iss": common.EnvConfig.AppURL,
 	}
@@ -151,19 +206,122 @@ func (s *JwtService) GenerateIDToken(userClaims map[string]interface{}, clientID
 		return "", errors.New("failed to generate key ID: " + err.Error())
 	}
 
+	signingMethod := jwt.GetSigningMethod(alg)
+	if signingMethod == nil {
+		return "", errors.New("unsupported signing algorithm: " + alg)
+	}
+
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(s.PrivateKey)
+}
+
+// GenerateUserinfoToken generates a signed userinfo response for clients that don't accept plain JSON
+func (s *JwtService) GenerateUserinfoToken(userClaims map[string]interface{}, clientID string) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": clientID,
+		"iat": jwt.NewNumericDate(time.Now()),
+		"iss": common.EnvConfig.AppURL,
+	}
+
+	for k, v := range userClaims {
+		claims[k] = v
+	}
+
+	kid, err := s.generateKeyID(s.PublicKey)
+	if err != nil {
+		return "", errors.New("failed to generate key ID: " + err.Error())
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 	token.Header["kid"] = kid
 
 	return token.SignedString(s.PrivateKey)
 }
 
-func (s *JwtService) GenerateOauthAccessToken(user model.User, clientID string) (string, error) {
-	claim := jwt.RegisteredClaims{
-		Subject:   user.ID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Audience:  jwt.ClaimStrings{clientID},
-		Issuer:    common.EnvConfig.AppURL,
+// GenerateAuthorizationResponse generates a JWT secured authorization response as defined by JARM that contains the parameters of the response
+func (s *JwtService) GenerateAuthorizationResponse(clientID string, parameters map[string]string) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": clientID,
+		"exp": jwt.NewNumericDate(time.Now().Add(authorizationResponseDuration)),
+		"iss": common.EnvConfig.AppURL,
+	}
+
+	for k, v := range parameters {
+		claims[k] = v
+	}
+
+	kid, err := s.generateKeyID(s.PublicKey)
+	if err != nil {
+		return "", errors.New("failed to generate key ID: " + err.Error())
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(s.PrivateKey)
+}
+
+// GenerateLogoutToken generates a logout token as defined by OpenID Connect Back-Channel Logout
+func (s *JwtService) GenerateLogoutToken(clientID, subject, sessionID string) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": clientID,
+		"exp": jwt.NewNumericDate(time.Now().Add(logoutTokenDuration)),
+		"iat": jwt.NewNumericDate(time.Now()),
+		"iss": common.EnvConfig.AppURL,
+		"jti": uuid.New().String(),
+		"sub": subject,
+		"events": map[string]interface{}{
+			"http://schemas.openid.net/event/backchannel-logout": map[string]interface{}{},
+		},
+	}
+
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+
+	kid, err := s.generateKeyID(s.PublicKey)
+	if err != nil {
+		return "", errors.New("failed to generate key ID: " + err.Error())
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	token.Header["typ"] = "logout+jwt"
+
+	return token.SignedString(s.PrivateKey)
+}
+
+// GenerateOauthAccessToken generates an access token for the client as defined by RFC 9068. The subject is either a user ID or, for the client credentials grant, the client ID.
+// The audience are the identifiers of the requested API resources or, if there are none, the client itself.
+// If a confirmation is passed, the token is bound to the key of the client. The actor is only set for exchanged tokens.
+func (s *JwtService) GenerateOauthAccessToken(subject string, clientID string, resources []string, scope string, user *dto.OidcAccessTokenUserDto, confirmation *dto.OidcTokenConfirmationDto, actor *dto.OidcTokenActorDto, lifetime time.Duration) (string, error) {
+	audience := jwt.ClaimStrings{clientID}
+	if len(resources) > 0 {
+		audience = resources
+	}
+
+	claim := OauthAccessTokenJWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Audience:  audience,
+			Issuer:    common.EnvConfig.AppURL,
+		},
+		ClientID: clientID,
+		Scope:    scope,
+		Cnf:      confirmation,
+		Act:      actor,
+	}
+	if user != nil {
+		if user.AuthTime != nil {
+			claim.AuthTime = jwt.NewNumericDate(*user.AuthTime)
+		}
+		claim.Groups = user.Groups
+		claim.Roles = user.Roles
 	}
 
 	kid, err := s.generateKeyID(s.PublicKey)
@@ -173,19 +331,24 @@ func (s *JwtService) GenerateOauthAccessToken(user model.User, clientID string)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
 	token.Header["kid"] = kid
+	token.Header["typ"] = oauthAccessTokenType
 
 	return token.SignedString(s.PrivateKey)
 }
 
-func (s *JwtService) VerifyOauthAccessToken(tokenString string) (*jwt.RegisteredClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+func (s *JwtService) VerifyOauthAccessToken(tokenString string) (*OauthAccessTokenJWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &OauthAccessTokenJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return s.PublicKey, nil
 	})
 	if err != nil || !token.Valid {
 		return nil, errors.New("couldn't handle this token")
 	}
 
-	claims, isValid := token.Claims.(*jwt.RegisteredClaims)
+	if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, oauthAccessTokenType) && !strings.EqualFold(typ, "application/"+oauthAccessTokenType) {
+		return nil, errors.New("token is not an access token")
+	}
+
+	claims, isValid := token.Claims.(*OauthAccessTokenJWTClaims)
 	if !isValid {
 		return nil, errors.New("can't parse claims")
 	}
@@ -211,6 +374,7 @@ func (s *JwtService) VerifyIdToken(tokenString string) (*jwt.RegisteredClaims, e
 }
 
 // GetJWK returns the JSON Web Key (JWK) for the public key.
+// The key has no alg member because the ID tokens are signed with the algorithm each client registered.
 func (s *JwtService) GetJWK() (JWK, error) {
 	if s.PublicKey == nil {
 		return JWK{}, errors.New("public key is not initialized")
@@ -225,7 +389,6 @@ func (s *JwtService) GetJWK() (JWK, error) {
 		Kid: kid,
 		Kty: "RSA",
 		Use: "sig",
-		Alg: "RS256",
 		N:   base64.RawURLEncoding.EncodeToString(s.PublicKey.N.Bytes()),
 		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.PublicKey.E)).Bytes()),
 	}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+)
+
+// newTestJwtService returns a JWT service with a freshly generated key
+func newTestJwtService(t *testing.T) *JwtService {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &JwtService{
+		PrivateKey: privateKey,
+		PublicKey:  &privateKey.PublicKey,
+		appConfigService: &AppConfigService{DbConfig: &model.AppConfig{
+			SessionDuration: model.AppConfigVariable{Value: "60"},
+		}},
+	}
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	s := newTestJwtService(t)
+
+	token, err := s.GenerateAccessToken(model.User{Base: model.Base{ID: pairwiseTestUserID}}, []string{"hwk"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := s.VerifyAccessToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims.Subject != pairwiseTestUserID {
+		t.Errorf("expected the user ID as subject, got: '%s'", claims.Subject)
+	}
+}
+
+func TestVerifyAccessTokenWithOauthAccessToken(t *testing.T) {
+	s := newTestJwtService(t)
+
+	// Even with the app URL as audience an access token issued to a client isn't a session
+	token, err := s.GenerateOauthAccessToken(pairwiseTestUserID, "client", []string{common.EnvConfig.AppURL}, "openid", nil, nil, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := s.VerifyAccessToken(token); err == nil {
+		t.Errorf("expected the access token to be refused as session token")
+	}
+}
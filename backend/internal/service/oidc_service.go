@@ -1,17 +1,26 @@
 package service
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pocket-id/pocket-id/backend/internal/common"
 	"github.com/pocket-id/pocket-id/backend/internal/dto"
 	"github.com/pocket-id/pocket-id/backend/internal/model"
@@ -21,81 +30,598 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	pushedAuthorizationRequestDuration  = 10 * time.Minute
+	pushedAuthorizationRequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+	deviceCodeDuration        = 15 * time.Minute
+	deviceCodePollingInterval = 5 * time.Second
+	// deviceCodeSlowDownIncrease is added to the polling interval every time the client polls too fast as defined by RFC 8628
+	deviceCodeSlowDownIncrease = 5 * time.Second
+
+	clientAssertionTypeJwtBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// clientAssertionMaxLifetime is how far in the future client assertions may expire. Their jti is stored until then.
+	clientAssertionMaxLifetime = 5 * time.Minute
+
+	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	// clientRequestTimeout is the timeout of requests to URLs of clients, like their JWKS or logo
+	clientRequestTimeout = 10 * time.Second
+	// clientKeysCacheDuration is how long the keys fetched from the JWKS URI of a client are reused
+	clientKeysCacheDuration = 5 * time.Minute
+
+	backchannelLogoutAttempts   = 3
+	backchannelLogoutRetryDelay = 5 * time.Second
+
+	pairwiseSubjectSecretPath = "data/keys/pairwise_subject_secret"
+
+	dpopProofLifetime = 5 * time.Minute
+	dpopNonceDuration = 5 * time.Minute
+
+	// promptLoginMaxAge is how recent the authentication has to be to satisfy prompt=login. The authorization page
+	// lets the user sign in again right before the client is authorized.
+	promptLoginMaxAge = time.Minute
+)
+
+// ClientSigningAlgorithms are the algorithms accepted for JWTs that are signed by clients
+var ClientSigningAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// IDTokenSigningAlgorithms are the algorithms the ID tokens can be signed with
+var IDTokenSigningAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+
+// UserinfoSigningAlgorithms are the algorithms the userinfo responses can be signed with
+var UserinfoSigningAlgorithms = []string{"RS256"}
+
+// ClientSecretSigningAlgorithms are the algorithms accepted for client assertions that are signed with the client secret
+var ClientSecretSigningAlgorithms = []string{"HS256", "HS384", "HS512"}
+
+// TokenEndpointAuthMethods are the methods clients can use to authenticate themselves at the OAuth endpoints
+var TokenEndpointAuthMethods = []string{"client_secret_basic", "client_secret_post", "client_secret_jwt", "private_key_jwt", "tls_client_auth", "self_signed_tls_client_auth", "none"}
+
+// ResponseTypes are the response types of the authorization endpoint. All but code require the implicit flow to be enabled for the client.
+var ResponseTypes = []string{"code", "id_token", "id_token token", "code id_token"}
+
+// The targets of the claims request parameter
+const (
+	ClaimsTargetUserinfo = "userinfo"
+	ClaimsTargetIDToken  = "id_token"
+)
+
+// SubjectTypes are the types of subject identifiers the clients can use
+var SubjectTypes = []string{"public", "pairwise"}
+
+// BuiltInScopes are the scopes that are always supported and can't be redefined by the admins
+var BuiltInScopes = []string{"openid", "profile", "email", "groups", "offline_access"}
+
+// builtInScopeClaims are the claims the built-in scopes release. The profile scope additionally releases the custom claims.
+var builtInScopeClaims = map[string][]string{
+	"profile": {"given_name", "family_name", "name", "preferred_username"},
+	"email":   {"email", "email_verified"},
+	"groups":  {"groups"},
+}
+
+// PromptValues are the values of the prompt parameter of the authorization endpoint
+var PromptValues = []string{"none", "login", "consent", "select_account"}
+
+// AcrValues are the authentication context classes of the ID tokens. Passkeys are phishing-resistant (phr) as defined by the
+// OpenID Connect Extended Authentication Profile, all other methods don't meet any level of assurance (0).
+var AcrValues = []string{"phr", "0"}
+
+// ResponseModes are the response modes of the authorization endpoint, including the JWT secured modes defined by JARM
+var ResponseModes = []string{"query", "fragment", "form_post", "query.jwt", "fragment.jwt", "form_post.jwt", "jwt"}
+
+// claimsRequest is the claims request parameter as defined by OpenID Connect Core 5.5.
+// It requests individual claims for the userinfo response and the ID token independently of the scope.
+type claimsRequest struct {
+	Userinfo map[string]*claimRequest `json:"userinfo"`
+	IDToken  map[string]*claimRequest `json:"id_token"`
+}
+
+// claimRequest contains the constraints of a requested claim. A null value requests the claim without constraints.
+type claimRequest struct {
+	Essential bool          `json:"essential"`
+	Value     interface{}   `json:"value"`
+	Values    []interface{} `json:"values"`
+}
+
+// parseClaimsRequest parses the claims request parameter. An empty parameter doesn't request any claims.
+func parseClaimsRequest(claims string) (claimsRequest, error) {
+	var request claimsRequest
+	if claims == "" {
+		return request, nil
+	}
+
+	if err := json.Unmarshal([]byte(claims), &request); err != nil {
+		return claimsRequest{}, &common.OidcInvalidRequestError{Message: "claims parameter is invalid"}
+	}
+
+	return request, nil
+}
+
+// names returns the names of the claims requested for the userinfo response or the ID token
+func (r claimsRequest) names() []string {
+	names := make([]string, 0, len(r.Userinfo)+len(r.IDToken))
+	for _, requested := range []map[string]*claimRequest{r.Userinfo, r.IDToken} {
+		for name := range requested {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// forTarget returns the claims requested for the userinfo response or the ID token
+func (r claimsRequest) forTarget(target string) map[string]*claimRequest {
+	if target == ClaimsTargetIDToken {
+		return r.IDToken
+	}
+	return r.Userinfo
+}
+
+// matches reports whether the value satisfies the value constraints of the request.
+// The values are compared as JSON because the requested values are decoded from JSON.
+func (r *claimRequest) matches(value interface{}) bool {
+	if r == nil || (r.Value == nil && r.Values == nil) {
+		return true
+	}
+
+	expectedValues := r.Values
+	if r.Value != nil {
+		expectedValues = append(expectedValues, r.Value)
+	}
+
+	actualValue, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, expectedValue := range expectedValues {
+		if expected, err := json.Marshal(expectedValue); err == nil && string(expected) == string(actualValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type OidcService struct {
 	db                 *gorm.DB
 	jwtService         *JwtService
 	appConfigService   *AppConfigService
 	auditLogService    *AuditLogService
 	customClaimService *CustomClaimService
+
+	// clientCAs are the certificate authorities that issue the certificates of clients using tls_client_auth
+	clientCAs *x509.CertPool
+	// dpopNonceKey is the key the DPoP nonces are derived from. Nonces become invalid when the server restarts.
+	dpopNonceKey []byte
+	// pairwiseSubjectSecret is the secret the pairwise subject identifiers are derived from
+	pairwiseSubjectSecret []byte
+	// clientKeysCache holds the keys fetched from the JWKS URIs of the clients by client ID
+	clientKeysCache      map[string]cachedClientKeys
+	clientKeysCacheMutex sync.Mutex
+}
+
+type cachedClientKeys struct {
+	jwksURI   string
+	keys      []utils.PublicJWK
+	expiresAt time.Time
 }
 
 func NewOidcService(db *gorm.DB, jwtService *JwtService, appConfigService *AppConfigService, auditLogService *AuditLogService, customClaimService *CustomClaimService) *OidcService {
-	return &OidcService{
+	service := &OidcService{
 		db:                 db,
 		jwtService:         jwtService,
 		appConfigService:   appConfigService,
 		auditLogService:    auditLogService,
 		customClaimService: customClaimService,
+		dpopNonceKey:       make([]byte, 32),
+		clientKeysCache:    make(map[string]cachedClientKeys),
+	}
+
+	if _, err := rand.Read(service.dpopNonceKey); err != nil {
+		log.Fatalf("Failed to generate DPoP nonce key: %v", err)
+	}
+
+	pairwiseSubjectSecret, err := loadOrGeneratePairwiseSubjectSecret()
+	if err != nil {
+		log.Fatalf("Failed to load pairwise subject secret: %v", err)
 	}
+	service.pairwiseSubjectSecret = pairwiseSubjectSecret
+
+	if common.EnvConfig.TlsClientCAFile != "" {
+		clientCAs, err := utils.LoadCertPool(common.EnvConfig.TlsClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to load client CA certificates: %v", err)
+		}
+		service.clientCAs = clientCAs
+	}
+
+	return service
 }
 
-func (s *OidcService) Authorize(input dto.AuthorizeOidcClientRequestDto, userID, ipAddress, userAgent string) (string, string, error) {
+// Authorize authorizes the client for the user and returns the authorization response. The authentication time and methods
+// are those of the current session of the user and are used to check the prompt and max_age parameters.
+func (s *OidcService) Authorize(input dto.AuthorizeOidcClientRequestDto, userID, loginSessionID string, authTime time.Time, authenticationMethods []string, ipAddress, userAgent string) (dto.AuthorizeOidcClientResponseDto, error) {
 	var client model.OidcClient
 	if err := s.db.Preload("AllowedUserGroups").First(&client, "id = ?", input.ClientID).Error; err != nil {
-		return "", "", err
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	input, err := s.resolveAuthorizationRequest(client, input, true)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	responseType, err := validateResponseType(client, input.ResponseType, input.Nonce)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+	issueCode := hasScope(responseType, "code")
+
+	responseMode, err := resolveResponseMode(input.ResponseMode, responseType)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
 	}
 
 	// If the client is not public, the code challenge must be provided
-	if client.IsPublic && input.CodeChallenge == "" {
-		return "", "", &common.OidcMissingCodeChallengeError{}
+	if client.IsPublic && issueCode && input.CodeChallenge == "" {
+		return dto.AuthorizeOidcClientResponseDto{}, &common.OidcMissingCodeChallengeError{}
 	}
 
 	// Get the callback URL of the client. Return an error if the provided callback URL is not allowed
-	callbackURL, err := s.getCallbackURL(client.CallbackURLs, input.CallbackURL)
+	callbackURL, err := s.getCallbackURL(client, client.CallbackURLs, input.CallbackURL)
 	if err != nil {
-		return "", "", err
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	prompts, err := parsePrompt(input.Prompt)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	requestedClaims, err := parseClaimsRequest(input.Claims)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	resources, err := s.resolveResources(client.ID, input.Resource, input.Scope)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	// With prompt=none the client expects the error in the authorization response instead of an interaction with the user
+	if err := s.checkAuthentication(client, input, userID, authTime, prompts, requestedClaims); err != nil {
+		var oauthErr common.OAuthError
+		if !slices.Contains(prompts, "none") || !errors.As(err, &oauthErr) {
+			return dto.AuthorizeOidcClientResponseDto{}, err
+		}
+		parameters := map[string]string{"error": oauthErr.OAuthErrorCode(), "error_description": err.Error()}
+		return s.authorizationResponse(client, callbackURL, responseMode, input.State, parameters)
+	}
+
+	if err := s.authorizeClient(client, userID, input.Scope, input.Claims, ipAddress, userAgent); err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	sessionID, err := s.updateSessionAuthentication(client.ID, userID, loginSessionID, authTime, authenticationMethods)
+	if err != nil {
+		return dto.AuthorizeOidcClientResponseDto{}, err
+	}
+
+	parameters := map[string]string{}
+
+	// Create the authorization code
+	if issueCode {
+		parameters["code"], err = s.createAuthorizationCode(client, userID, sessionID, input.Scope, resources, input.Nonce, input.CodeChallenge, input.CodeChallengeMethod)
+		if err != nil {
+			return dto.AuthorizeOidcClientResponseDto{}, err
+		}
+	}
+
+	// The implicit and hybrid flows return the tokens directly from the authorization endpoint
+	if responseType != "code" {
+		if err := s.addAuthorizationResponseTokens(parameters, client, userID, sessionID, input.Scope, resources, input.Nonce, hasScope(responseType, "token")); err != nil {
+			return dto.AuthorizeOidcClientResponseDto{}, err
+		}
+	}
+
+	return s.authorizationResponse(client, callbackURL, responseMode, input.State, parameters)
+}
+
+// authorizationResponse returns the response that passes the parameters to the callback URL of the client
+func (s *OidcService) authorizationResponse(client model.OidcClient, callbackURL, responseMode, state string, parameters map[string]string) (dto.AuthorizeOidcClientResponseDto, error) {
+	if state != "" {
+		parameters["state"] = state
+	}
+
+	// The parameters of a JWT secured response are signed, so the client can verify that they were issued by us
+	responseMode, jwtSecured := strings.CutSuffix(responseMode, ".jwt")
+	if jwtSecured {
+		signedResponse, err := s.jwtService.GenerateAuthorizationResponse(client.ID, parameters)
+		if err != nil {
+			return dto.AuthorizeOidcClientResponseDto{}, err
+		}
+		parameters = map[string]string{"response": signedResponse}
+	}
+
+	return dto.AuthorizeOidcClientResponseDto{
+		CallbackURL:  callbackURL,
+		ResponseMode: responseMode,
+		Parameters:   parameters,
+	}, nil
+}
+
+// parsePrompt returns the values of the prompt parameter. The value none can't be combined with other values.
+func parsePrompt(prompt string) ([]string, error) {
+	prompts := strings.Fields(prompt)
+	for _, value := range prompts {
+		if !slices.Contains(PromptValues, value) {
+			return nil, &common.OidcInvalidRequestError{Message: "prompt value " + value + " is not supported"}
+		}
+	}
+
+	if slices.Contains(prompts, "none") && len(prompts) > 1 {
+		return nil, &common.OidcInvalidRequestError{Message: "prompt none can't be combined with other values"}
+	}
+
+	return prompts, nil
+}
+
+// checkAuthentication returns an error if the user has to sign in again or, with prompt=none, has to authorize the client first
+func (s *OidcService) checkAuthentication(client model.OidcClient, input dto.AuthorizeOidcClientRequestDto, userID string, authTime time.Time, prompts []string, requestedClaims claimsRequest) error {
+	if userID == "" {
+		if slices.Contains(prompts, "none") {
+			return &common.OidcLoginRequiredError{}
+		}
+		return &common.NotSignedInError{}
+	}
+
+	// There is only one account per session, so selecting an account means signing in again
+	if (slices.Contains(prompts, "login") || slices.Contains(prompts, "select_account")) && time.Since(authTime) > promptLoginMaxAge {
+		return &common.OidcLoginRequiredError{}
+	}
+
+	if input.MaxAge != nil && time.Since(authTime) > time.Duration(*input.MaxAge)*time.Second {
+		return &common.OidcLoginRequiredError{}
+	}
+
+	// A client that requests the sub claim with a value expects a specific user
+	subject, err := s.subjectForClient(client, userID)
+	if err != nil {
+		return err
+	}
+	if !requestedClaims.forTarget(ClaimsTargetIDToken)["sub"].matches(subject) {
+		return &common.OidcLoginRequiredError{}
+	}
+
+	if input.LoginHint != "" {
+		var user model.User
+		if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if !strings.EqualFold(input.LoginHint, user.Username) && !strings.EqualFold(input.LoginHint, user.Email) {
+			return &common.OidcLoginRequiredError{}
+		}
+	}
+
+	if slices.Contains(prompts, "none") {
+		hasAuthorizedClient, err := s.HasAuthorizedClient(client.ID, userID, input.Scope, input.Claims)
+		if err != nil {
+			return err
+		}
+		if !hasAuthorizedClient {
+			return &common.OidcConsentRequiredError{}
+		}
+	}
+
+	return nil
+}
+
+// updateSessionAuthentication records how the user authenticated, so that the ID tokens of the session contain it, and returns
+// the ID of the session. There is one session per client and login session, so that logging out of Pocket ID only ends the
+// sessions at the clients the user signed in to with it.
+func (s *OidcService) updateSessionAuthentication(clientID, userID, loginSessionID string, authTime time.Time, authenticationMethods []string) (string, error) {
+	var session model.OidcSession
+	sessionAuthTime := datatype.DateTime(authTime)
+	err := s.db.
+		Where(model.OidcSession{UserID: userID, ClientID: clientID, LoginSessionID: loginSessionID}).
+		Assign(model.OidcSession{AuthTime: &sessionAuthTime, Amr: authenticationMethods}).
+		FirstOrCreate(&session).Error
+	return session.ID, err
+}
+
+// resolveResponseMode returns the response mode that is used for the response type.
+// Tokens must not be passed in the query because it could end up in logs or the referrer.
+func resolveResponseMode(responseMode, responseType string) (string, error) {
+	if responseMode != "" && !slices.Contains(ResponseModes, responseMode) {
+		return "", &common.OidcInvalidRequestError{Message: "response_mode is not supported"}
+	}
+
+	defaultResponseMode := "query"
+	if responseType != "code" {
+		defaultResponseMode = "fragment"
+	}
+
+	switch responseMode {
+	case "":
+		return defaultResponseMode, nil
+	case "jwt":
+		return defaultResponseMode + ".jwt", nil
+	case "query", "query.jwt":
+		if responseType != "code" {
+			return "", &common.OidcInvalidRequestError{Message: "the query response mode can't be used with this response type"}
+		}
+	}
+
+	return responseMode, nil
+}
+
+// validateResponseType returns the normalized response type if the client is allowed to use it.
+// The tokens of the implicit and hybrid flows can only be tied to the authorization request with a nonce.
+func validateResponseType(client model.OidcClient, responseType, nonce string) (string, error) {
+	// The response type was always code before other response types were supported
+	if responseType == "" {
+		return "code", nil
+	}
+
+	responseType = normalizeResponseType(responseType)
+	if !slices.Contains(ResponseTypes, responseType) {
+		return "", &common.OidcUnsupportedResponseTypeError{}
+	}
+	if responseType == "code" {
+		return responseType, nil
+	}
+
+	if !client.ImplicitFlowEnabled {
+		return "", &common.OidcResponseTypeNotAllowedError{}
+	}
+	if nonce == "" {
+		return "", &common.OidcInvalidRequestError{Message: "nonce is required for the implicit and hybrid flows"}
+	}
+
+	return responseType, nil
+}
+
+// normalizeResponseType sorts the values of the response type because their order doesn't matter
+func normalizeResponseType(responseType string) string {
+	values := strings.Fields(responseType)
+	slices.Sort(values)
+	return strings.Join(values, " ")
+}
+
+// addAuthorizationResponseTokens adds the ID token and optionally an access token to the response of the authorization endpoint.
+// The ID token contains the hashes of the code and the access token, so the client can verify that they belong together.
+func (s *OidcService) addAuthorizationResponseTokens(parameters map[string]string, client model.OidcClient, userID, sessionID, scope string, resources []string, nonce string, issueAccessToken bool) error {
+	userClaims, err := s.getIDTokenClaims(userID, client.ID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if code, ok := parameters["code"]; ok {
+		userClaims["c_hash"] = tokenHash(code, idTokenSigningAlgorithm(client))
+	}
+
+	lifetimes := s.tokenLifetimes(client)
+	if issueAccessToken {
+		accessToken, err := s.jwtService.GenerateOauthAccessToken(userClaims["sub"].(string), client.ID, resources, scope, accessTokenUser(userClaims, scope), nil, nil, lifetimes.accessToken)
+		if err != nil {
+			return err
+		}
+		parameters["access_token"] = accessToken
+		parameters["token_type"] = "Bearer"
+		parameters["expires_in"] = strconv.Itoa(int(lifetimes.accessToken.Seconds()))
+		userClaims["at_hash"] = tokenHash(accessToken, idTokenSigningAlgorithm(client))
+	}
+
+	parameters["id_token"], err = s.generateIDToken(client, userClaims, nonce)
+	return err
+}
+
+// accessTokenUser returns the claims about the user that access tokens carry as defined by RFC 9068.
+// The groups and the roles, which an admin can release with a custom scope, are only included if the scope grants them.
+func accessTokenUser(userClaims map[string]interface{}, scope string) *dto.OidcAccessTokenUserDto {
+	user := &dto.OidcAccessTokenUserDto{}
+	if authTime, ok := userClaims["auth_time"].(int64); ok {
+		authTime := time.Unix(authTime, 0)
+		user.AuthTime = &authTime
 	}
+	if groups, ok := userClaims["groups"].([]string); ok && hasScope(scope, "groups") {
+		user.Groups = groups
+	}
+	if roles, ok := userClaims["roles"]; ok && hasScope(scope, "roles") {
+		user.Roles = roles
+	}
+	return user
+}
+
+// tokenHash returns the left-most half of the hash of the value as used by the c_hash and at_hash claims.
+// The hash function is the one of the algorithm the ID token is signed with.
+func tokenHash(value, alg string) string {
+	var hash []byte
+	switch {
+	case strings.HasSuffix(alg, "384"):
+		sum := sha512.Sum384([]byte(value))
+		hash = sum[:]
+	case strings.HasSuffix(alg, "512"):
+		sum := sha512.Sum512([]byte(value))
+		hash = sum[:]
+	default:
+		sum := sha256.Sum256([]byte(value))
+		hash = sum[:]
+	}
+	return base64.RawURLEncoding.EncodeToString(hash[:len(hash)/2])
+}
 
+// authorizeClient checks if the user is allowed to authorize the client, stores the authorization and logs the event
+func (s *OidcService) authorizeClient(client model.OidcClient, userID, scope, claims, ipAddress, userAgent string) error {
 	// Check if the user group is allowed to authorize the client
 	var user model.User
 	if err := s.db.Preload("UserGroups").First(&user, "id = ?", userID).Error; err != nil {
-		return "", "", err
+		return err
 	}
 
 	if !s.IsUserGroupAllowedToAuthorize(user, client) {
-		return "", "", &common.OidcAccessDeniedError{}
+		return &common.OidcAccessDeniedError{}
 	}
 
-	// Check if the user has already authorized the client with the given scope
-	hasAuthorizedClient, err := s.HasAuthorizedClient(input.ClientID, userID, input.Scope)
+	// Check if the user has already authorized the client with the given scope and claims
+	hasAuthorizedClient, err := s.HasAuthorizedClient(client.ID, userID, scope, claims)
 	if err != nil {
-		return "", "", err
+		return err
 	}
 
+	requestedClaims, err := parseClaimsRequest(claims)
+	if err != nil {
+		return err
+	}
+
+	now := datatype.DateTime(time.Now())
+
 	// If the user has not authorized the client, create a new authorization in the database
 	if !hasAuthorizedClient {
 		userAuthorizedClient := model.UserAuthorizedOidcClient{
-			UserID:   userID,
-			ClientID: input.ClientID,
-			Scope:    input.Scope,
+			UserID:           userID,
+			ClientID:         client.ID,
+			Scope:            scope,
+			Claims:           claims,
+			ConsentedClaims:  requestedClaims.names(),
+			CreatedAt:        &now,
+			LastAuthorizedAt: &now,
 		}
 
 		if err := s.db.Create(&userAuthorizedClient).Error; err != nil {
 			if errors.Is(err, gorm.ErrDuplicatedKey) {
-				// The client has already been authorized but with a different scope so we need to update the scope
-				if err := s.db.Model(&userAuthorizedClient).Update("scope", input.Scope).Error; err != nil {
-					return "", "", err
+				// The client has already been authorized but with other scopes or claims, so the new ones are added to the granted ones
+				var existingAuthorization model.UserAuthorizedOidcClient
+				if err := s.db.First(&existingAuthorization, "user_id = ? AND client_id = ?", userID, client.ID).Error; err != nil {
+					return err
+				}
+
+				err := s.db.Model(&model.UserAuthorizedOidcClient{}).
+					Where("user_id = ? AND client_id = ?", userID, client.ID).
+					Updates(map[string]interface{}{
+						"scope":              mergeScopes(existingAuthorization.Scope, scope),
+						"claims":             claims,
+						"consented_claims":   mergeClaimNames(existingAuthorization.ConsentedClaims, requestedClaims.names()),
+						"last_authorized_at": &now,
+					}).Error
+				if err != nil {
+					return err
 				}
 			} else {
-				return "", "", err
+				return err
 			}
 		}
-	}
-
-	// Create the authorization code
-	code, err := s.createAuthorizationCode(input.ClientID, userID, input.Scope, input.Nonce, input.CodeChallenge, input.CodeChallengeMethod)
-	if err != nil {
-		return "", "", err
+	} else {
+		// The claims are requested per authorization, so the latest request replaces the previous one
+		err := s.db.Model(&model.UserAuthorizedOidcClient{}).
+			Where("user_id = ? AND client_id = ?", userID, client.ID).
+			Updates(map[string]interface{}{"claims": claims, "last_authorized_at": &now}).Error
+		if err != nil {
+			return err
+		}
 	}
 
 	// Log the authorization event
@@ -103,14 +629,19 @@ func (s *OidcService) Authorize(input dto.AuthorizeOidcClientRequestDto, userID,
 		s.auditLogService.Create(model.AuditLogEventClientAuthorization, ipAddress, userAgent, userID, model.AuditLogData{"clientName": client.Name})
 	} else {
 		s.auditLogService.Create(model.AuditLogEventNewClientAuthorization, ipAddress, userAgent, userID, model.AuditLogData{"clientName": client.Name})
-
 	}
 
-	return code, callbackURL, nil
+	return nil
 }
 
-// HasAuthorizedClient checks if the user has already authorized the client with the given scope
-func (s *OidcService) HasAuthorizedClient(clientID, userID, scope string) (bool, error) {
+// HasAuthorizedClient checks if the user has already authorized the client with all scopes of the given scope and the claims
+// of the claims request parameter. The order of the scopes doesn't matter and a subset of the granted scopes doesn't require a new consent.
+func (s *OidcService) HasAuthorizedClient(clientID, userID, scope, claims string) (bool, error) {
+	requestedClaims, err := parseClaimsRequest(claims)
+	if err != nil {
+		return false, err
+	}
+
 	var userAuthorizedOidcClient model.UserAuthorizedOidcClient
 	if err := s.db.First(&userAuthorizedOidcClient, "client_id = ? AND user_id = ?", clientID, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -119,86 +650,370 @@ func (s *OidcService) HasAuthorizedClient(clientID, userID, scope string) (bool,
 		return false, err
 	}
 
-	if userAuthorizedOidcClient.Scope != scope {
+	if !containsScopes(userAuthorizedOidcClient.Scope, scope) {
 		return false, nil
 	}
 
+	// Individually requested claims are covered by the consent if they were requested or granted by a scope before
+	grantedClaims, err := s.grantedClaims(userAuthorizedOidcClient.Scope)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range requestedClaims.names() {
+		if name != "sub" && !grantedClaims[name] && !slices.Contains(userAuthorizedOidcClient.ConsentedClaims, name) {
+			return false, nil
+		}
+	}
+
 	return true, nil
 }
 
-// IsUserGroupAllowedToAuthorize checks if the user group of the user is allowed to authorize the client
-func (s *OidcService) IsUserGroupAllowedToAuthorize(user model.User, client model.OidcClient) bool {
-	if len(client.AllowedUserGroups) == 0 {
-		return true
+// IsUserGroupAllowedToAuthorize checks if the user group of the user is allowed to authorize the client
+func (s *OidcService) IsUserGroupAllowedToAuthorize(user model.User, client model.OidcClient) bool {
+	if len(client.AllowedUserGroups) == 0 {
+		return true
+	}
+
+	isAllowedToAuthorize := false
+	for _, userGroup := range client.AllowedUserGroups {
+		for _, userGroupUser := range user.UserGroups {
+			if userGroup.ID == userGroupUser.ID {
+				isAllowedToAuthorize = true
+				break
+			}
+		}
+	}
+
+	return isAllowedToAuthorize
+}
+
+func (s *OidcService) CreateTokens(input dto.OidcCreateTokensDto, ipAddress, userAgent string) (dto.OidcTokenResponseDto, error) {
+	switch input.GrantType {
+	case "authorization_code":
+		return s.createTokensFromAuthorizationCode(input)
+	case "refresh_token":
+		return s.createTokensFromRefreshToken(input)
+	case "client_credentials":
+		return s.createTokensFromClientCredentials(input, ipAddress, userAgent)
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		return s.createTokensFromDeviceCode(input)
+	case "urn:ietf:params:oauth:grant-type:token-exchange":
+		return s.createTokensFromTokenExchange(input, ipAddress, userAgent)
+	default:
+		return dto.OidcTokenResponseDto{}, &common.OidcGrantTypeNotSupportedError{}
+	}
+}
+
+func (s *OidcService) createTokensFromAuthorizationCode(input dto.OidcCreateTokensDto) (dto.OidcTokenResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	var authorizationCodeMetaData model.OidcAuthorizationCode
+	err = s.db.Preload("User").First(&authorizationCodeMetaData, "code = ?", input.Code).Error
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidAuthorizationCodeError{}
+	}
+
+	// If the client is public or PKCE is enabled, the code verifier must match the code challenge
+	if client.IsPublic || client.PkceEnabled {
+		if !s.validateCodeVerifier(input.CodeVerifier, *authorizationCodeMetaData.CodeChallenge, *authorizationCodeMetaData.CodeChallengeMethodSha256) {
+			return dto.OidcTokenResponseDto{}, &common.OidcInvalidCodeVerifierError{}
+		}
+	}
+
+	if authorizationCodeMetaData.ClientID != client.ID || authorizationCodeMetaData.ExpiresAt.ToTime().Before(time.Now()) {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidAuthorizationCodeError{}
+	}
+
+	confirmation, err := s.tokenConfirmation(client, input)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// The access token can be restricted to some of the resources the client was authorized for
+	audience, err := narrowResources(authorizationCodeMetaData.Resources, input.Resource)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// The code is consumed before the tokens are issued. Only the request that deletes it gets tokens,
+	// so that concurrent requests can't redeem the same code twice.
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&authorizationCodeMetaData)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &common.OidcInvalidAuthorizationCodeError{}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	return s.createTokenResponseForUser(client, authorizationCodeMetaData.UserID, authorizationCodeMetaData.SessionID, authorizationCodeMetaData.Scope, authorizationCodeMetaData.Resources, audience, authorizationCodeMetaData.Nonce, confirmation)
+}
+
+// createTokenResponseForUser generates the ID and access token for the user and a refresh token if offline access was requested.
+// The access token is issued for the audience, while the refresh token keeps all granted resources.
+func (s *OidcService) createTokenResponseForUser(client model.OidcClient, userID, sessionID, scope string, resources, audience []string, nonce string, confirmation *dto.OidcTokenConfirmationDto) (dto.OidcTokenResponseDto, error) {
+	userClaims, err := s.getIDTokenClaims(userID, client.ID, sessionID)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	lifetimes := s.tokenLifetimes(client)
+	idToken, err := s.generateIDToken(client, userClaims, nonce)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	accessToken, err := s.jwtService.GenerateOauthAccessToken(userClaims["sub"].(string), client.ID, audience, scope, accessTokenUser(userClaims, scope), confirmation, nil, lifetimes.accessToken)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// Only issue a refresh token if the client requested offline access
+	var refreshToken string
+	if hasScope(scope, "offline_access") {
+		refreshToken, err = s.createRefreshToken(s.db, client, userID, sessionID, scope, resources, "", refreshTokenJkt(client, confirmation))
+		if err != nil {
+			return dto.OidcTokenResponseDto{}, err
+		}
+	}
+
+	return dto.OidcTokenResponseDto{
+		AccessToken:  accessToken,
+		TokenType:    tokenType(confirmation),
+		ExpiresIn:    int(lifetimes.accessToken.Seconds()),
+		IdToken:      idToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *OidcService) createTokensFromRefreshToken(input dto.OidcCreateTokensDto) (dto.OidcTokenResponseDto, error) {
+	if input.RefreshToken == "" {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRefreshTokenError{}
+	}
+
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	var storedRefreshToken model.OidcRefreshToken
+	err = s.db.Preload("User.UserGroups").First(&storedRefreshToken, "token = ? AND client_id = ?", utils.CreateSha256Hash(input.RefreshToken), client.ID).Error
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRefreshTokenError{}
+	}
+
+	// A refresh token that was already used indicates that it might have been stolen, so the whole token family gets revoked
+	if storedRefreshToken.Used {
+		if err := s.db.Delete(&model.OidcRefreshToken{}, "family_id = ?", storedRefreshToken.FamilyID).Error; err != nil {
+			return dto.OidcTokenResponseDto{}, err
+		}
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRefreshTokenError{}
+	}
+
+	if storedRefreshToken.ExpiresAt.ToTime().Before(time.Now()) {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRefreshTokenError{}
+	}
+
+	// Check if the user group is still allowed to authorize the client
+	if !s.IsUserGroupAllowedToAuthorize(storedRefreshToken.User, client) {
+		return dto.OidcTokenResponseDto{}, &common.OidcAccessDeniedError{}
+	}
+
+	// The requested scope can't exceed the scope that was originally granted
+	scope := storedRefreshToken.Scope
+	if input.Scope != "" {
+		for _, requestedScope := range strings.Fields(input.Scope) {
+			if !hasScope(storedRefreshToken.Scope, requestedScope) {
+				return dto.OidcTokenResponseDto{}, &common.OidcInvalidScopeError{}
+			}
+		}
+		scope = input.Scope
+	}
+
+	// The admin might have revoked the access of the client to some of the resources in the meantime
+	allowed, _, err := s.allowedResources(client.ID)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+	resources := []string{}
+	for _, resource := range allowed {
+		if slices.Contains(storedRefreshToken.Resources, resource.Identifier) {
+			resources = append(resources, resource.Identifier)
+		}
+	}
+	audience, err := narrowResources(resources, input.Resource)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	confirmation, err := s.tokenConfirmation(client, input)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// Refresh tokens of public clients can only be used with the DPoP key they are bound to
+	if storedRefreshToken.DpopJkt != "" && (confirmation == nil || confirmation.Jkt != storedRefreshToken.DpopJkt) {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRefreshTokenError{}
+	}
+
+	// Rotate the refresh token
+	var refreshToken string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.OidcRefreshToken{}).Where("id = ? AND used = ?", storedRefreshToken.ID, false).Update("used", true)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Another request has used the refresh token in the meantime
+		if result.RowsAffected == 0 {
+			return &common.OidcInvalidRefreshTokenError{}
+		}
+
+		refreshToken, err = s.createRefreshToken(tx, client, storedRefreshToken.UserID, storedRefreshToken.SessionID, scope, resources, storedRefreshToken.FamilyID, storedRefreshToken.DpopJkt)
+		return err
+	})
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	userClaims, err := s.getIDTokenClaims(storedRefreshToken.UserID, client.ID, storedRefreshToken.SessionID)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	lifetimes := s.tokenLifetimes(client)
+	idToken, err := s.generateIDToken(client, userClaims, "")
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	accessToken, err := s.jwtService.GenerateOauthAccessToken(userClaims["sub"].(string), client.ID, audience, scope, accessTokenUser(userClaims, scope), confirmation, nil, lifetimes.accessToken)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	return dto.OidcTokenResponseDto{
+		AccessToken:  accessToken,
+		TokenType:    tokenType(confirmation),
+		ExpiresIn:    int(lifetimes.accessToken.Seconds()),
+		IdToken:      idToken,
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}, nil
+}
+
+func (s *OidcService) createTokensFromClientCredentials(input dto.OidcCreateTokensDto, ipAddress, userAgent string) (dto.OidcTokenResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// Only confidential clients with allowed scopes can use the client credentials grant
+	if client.IsPublic || len(client.ClientCredentialsScopes) == 0 {
+		return dto.OidcTokenResponseDto{}, &common.OidcUnauthorizedClientError{}
 	}
 
-	isAllowedToAuthorize := false
-	for _, userGroup := range client.AllowedUserGroups {
-		for _, userGroupUser := range user.UserGroups {
-			if userGroup.ID == userGroupUser.ID {
-				isAllowedToAuthorize = true
-				break
+	// If no scope is requested, all allowed scopes are granted
+	scope := strings.Join(client.ClientCredentialsScopes, " ")
+	if input.Scope != "" {
+		for _, requestedScope := range strings.Fields(input.Scope) {
+			if !slices.Contains(client.ClientCredentialsScopes, requestedScope) {
+				return dto.OidcTokenResponseDto{}, &common.OidcInvalidScopeError{}
 			}
 		}
+		scope = input.Scope
 	}
 
-	return isAllowedToAuthorize
-}
+	resources, err := s.resolveResources(client.ID, input.Resource, scope)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
 
-func (s *OidcService) CreateTokens(code, grantType, clientID, clientSecret, codeVerifier string) (string, string, error) {
-	if grantType != "authorization_code" {
-		return "", "", &common.OidcGrantTypeNotSupportedError{}
+	confirmation, err := s.tokenConfirmation(client, input)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
 	}
 
-	var client model.OidcClient
-	if err := s.db.First(&client, "id = ?", clientID).Error; err != nil {
-		return "", "", err
+	// The client acts on its own behalf, so it is the subject of the token
+	accessTokenLifetime := s.tokenLifetimes(client).accessToken
+	accessToken, err := s.jwtService.GenerateOauthAccessToken(client.ID, client.ID, resources, scope, nil, confirmation, nil, accessTokenLifetime)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
 	}
 
-	// Verify the client secret if the client is not public
-	if !client.IsPublic {
-		if clientID == "" || clientSecret == "" {
-			return "", "", &common.OidcMissingClientCredentialsError{}
-		}
+	s.auditLogService.Create(model.AuditLogEventClientCredentialsGrant, ipAddress, userAgent, "", model.AuditLogData{"clientName": client.Name, "clientId": client.ID, "scope": scope})
 
-		err := bcrypt.CompareHashAndPassword([]byte(client.Secret), []byte(clientSecret))
-		if err != nil {
-			return "", "", &common.OidcClientSecretInvalidError{}
-		}
+	return dto.OidcTokenResponseDto{
+		AccessToken: accessToken,
+		TokenType:   tokenType(confirmation),
+		ExpiresIn:   int(accessTokenLifetime.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// AuthorizedClientID returns the ID of the client the user authorized to obtain the access token.
+// Exchanged tokens are based on the authorization of the first actor of the delegation chain.
+func AuthorizedClientID(claims *OauthAccessTokenJWTClaims) string {
+	clientID := claims.GetClientID()
+	for actor := claims.Act; actor != nil; actor = actor.Act {
+		clientID = actor.Sub
 	}
+	return clientID
+}
 
-	var authorizationCodeMetaData model.OidcAuthorizationCode
-	err := s.db.Preload("User").First(&authorizationCodeMetaData, "code = ?", code).Error
+// VerifyOauthAccessToken verifies the signature of the access token and checks that it wasn't revoked
+// and that the grant it was issued for still exists
+func (s *OidcService) VerifyOauthAccessToken(token string) (*OauthAccessTokenJWTClaims, error) {
+	claims, err := s.jwtService.VerifyOauthAccessToken(token)
 	if err != nil {
-		return "", "", &common.OidcInvalidAuthorizationCodeError{}
+		return nil, &common.TokenInvalidError{}
 	}
 
-	// If the client is public or PKCE is enabled, the code verifier must match the code challenge
-	if client.IsPublic || client.PkceEnabled {
-		if !s.validateCodeVerifier(codeVerifier, *authorizationCodeMetaData.CodeChallenge, *authorizationCodeMetaData.CodeChallengeMethodSha256) {
-			return "", "", &common.OidcInvalidCodeVerifierError{}
-		}
+	if claims.GetClientID() == "" {
+		return nil, &common.TokenInvalidError{}
 	}
 
-	if authorizationCodeMetaData.ClientID != clientID && authorizationCodeMetaData.ExpiresAt.ToTime().Before(time.Now()) {
-		return "", "", &common.OidcInvalidAuthorizationCodeError{}
+	var revokedCount int64
+	if err := s.db.Model(&model.OidcRevokedToken{}).Where("id = ?", claims.ID).Count(&revokedCount).Error; err != nil {
+		return nil, err
+	}
+	if revokedCount > 0 {
+		return nil, &common.TokenInvalidError{}
 	}
 
-	userClaims, err := s.GetUserClaimsForClient(authorizationCodeMetaData.UserID, clientID)
-	if err != nil {
-		return "", "", err
+	var client model.OidcClient
+	if err := s.db.First(&client, "id = ?", claims.GetClientID()).Error; err != nil {
+		return nil, &common.TokenInvalidError{}
 	}
 
-	idToken, err := s.jwtService.GenerateIDToken(userClaims, clientID, authorizationCodeMetaData.Nonce)
-	if err != nil {
-		return "", "", err
+	// Tokens issued with the client credentials grant have the client as subject
+	if claims.Subject == client.ID {
+		return claims, nil
 	}
 
-	accessToken, err := s.jwtService.GenerateOauthAccessToken(authorizationCodeMetaData.User, clientID)
+	userID, err := s.UserIDFromSubject(claims.Subject)
+	if err != nil {
+		return nil, err
+	}
 
-	s.db.Delete(&authorizationCodeMetaData)
+	// The user must not have revoked the authorization of the client
+	var count int64
+	if err := s.db.Model(&model.UserAuthorizedOidcClient{}).Where("user_id = ? AND client_id = ?", userID, AuthorizedClientID(claims)).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, &common.TokenInvalidError{}
+	}
 
-	return idToken, accessToken, nil
+	return claims, nil
 }
 
 func (s *OidcService) GetClient(clientID string) (model.OidcClient, error) {
@@ -234,6 +1049,69 @@ func (s *OidcService) CreateClient(input dto.OidcClientCreateDto, userID string)
 		CreatedByID:        userID,
 		IsPublic:           input.IsPublic,
 		PkceEnabled:        input.IsPublic || input.PkceEnabled,
+		RequirePar:         input.RequirePar,
+
+		RequireSignedRequest: input.RequireSignedRequest,
+		RequestURIs:          input.RequestURIs,
+		RequireDpop:          input.RequireDpop,
+		ImplicitFlowEnabled:  input.ImplicitFlowEnabled,
+		Jwks:                 input.Jwks,
+		JwksURI:              input.JwksURI,
+
+		TlsClientAuthSubjectDN: input.TlsClientAuthSubjectDN,
+		BackchannelLogoutURI:   input.BackchannelLogoutURI,
+
+		FrontchannelLogoutURI:             input.FrontchannelLogoutURI,
+		FrontchannelLogoutSessionRequired: input.FrontchannelLogoutSessionRequired,
+
+		SubjectType:         input.SubjectType,
+		SectorIdentifierURI: input.SectorIdentifierURI,
+
+		IDTokenSignedResponseAlg:     input.IDTokenSignedResponseAlg,
+		IDTokenEncryptedResponseAlg:  input.IDTokenEncryptedResponseAlg,
+		IDTokenEncryptedResponseEnc:  input.IDTokenEncryptedResponseEnc,
+		UserinfoSignedResponseAlg:    input.UserinfoSignedResponseAlg,
+		UserinfoEncryptedResponseAlg: input.UserinfoEncryptedResponseAlg,
+		UserinfoEncryptedResponseEnc: input.UserinfoEncryptedResponseEnc,
+
+		IDTokenLifetime:           input.IDTokenLifetime,
+		AccessTokenLifetime:       input.AccessTokenLifetime,
+		RefreshTokenLifetime:      input.RefreshTokenLifetime,
+		AuthorizationCodeLifetime: input.AuthorizationCodeLifetime,
+	}
+
+	if err := validateClientKeys(client.Jwks); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateRequestURIs(client.RequestURIs); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateLogoutURI(client.BackchannelLogoutURI); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateLogoutURI(client.FrontchannelLogoutURI); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := s.validateSubjectType(&client); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateResponseEncryption(&client); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := setTokenEndpointAuthMethod(&client, input.TokenEndpointAuthMethod); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	// Public clients can't authenticate themselves, so they can't use the client credentials grant or exchange tokens
+	if !client.IsPublic {
+		client.ClientCredentialsScopes = input.ClientCredentialsScopes
+		client.TokenExchangeAudiences = input.TokenExchangeAudiences
 	}
 
 	if err := s.db.Create(&client).Error; err != nil {
@@ -254,6 +1132,63 @@ func (s *OidcService) UpdateClient(clientID string, input dto.OidcClientCreateDt
 	client.LogoutCallbackURLs = input.LogoutCallbackURLs
 	client.IsPublic = input.IsPublic
 	client.PkceEnabled = input.IsPublic || input.PkceEnabled
+	client.RequirePar = input.RequirePar
+	client.RequireSignedRequest = input.RequireSignedRequest
+	client.RequestURIs = input.RequestURIs
+	client.RequireDpop = input.RequireDpop
+	client.ImplicitFlowEnabled = input.ImplicitFlowEnabled
+	client.Jwks = input.Jwks
+	client.JwksURI = input.JwksURI
+	client.TlsClientAuthSubjectDN = input.TlsClientAuthSubjectDN
+	client.BackchannelLogoutURI = input.BackchannelLogoutURI
+	client.FrontchannelLogoutURI = input.FrontchannelLogoutURI
+	client.FrontchannelLogoutSessionRequired = input.FrontchannelLogoutSessionRequired
+	client.SubjectType = input.SubjectType
+	client.SectorIdentifierURI = input.SectorIdentifierURI
+	client.IDTokenSignedResponseAlg = input.IDTokenSignedResponseAlg
+	client.IDTokenEncryptedResponseAlg = input.IDTokenEncryptedResponseAlg
+	client.IDTokenEncryptedResponseEnc = input.IDTokenEncryptedResponseEnc
+	client.UserinfoSignedResponseAlg = input.UserinfoSignedResponseAlg
+	client.UserinfoEncryptedResponseAlg = input.UserinfoEncryptedResponseAlg
+	client.UserinfoEncryptedResponseEnc = input.UserinfoEncryptedResponseEnc
+	client.IDTokenLifetime = input.IDTokenLifetime
+	client.AccessTokenLifetime = input.AccessTokenLifetime
+	client.RefreshTokenLifetime = input.RefreshTokenLifetime
+	client.AuthorizationCodeLifetime = input.AuthorizationCodeLifetime
+	client.ClientCredentialsScopes = nil
+	client.TokenExchangeAudiences = nil
+	if !client.IsPublic {
+		client.ClientCredentialsScopes = input.ClientCredentialsScopes
+		client.TokenExchangeAudiences = input.TokenExchangeAudiences
+	}
+
+	if err := validateClientKeys(client.Jwks); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateRequestURIs(client.RequestURIs); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateLogoutURI(client.BackchannelLogoutURI); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateLogoutURI(client.FrontchannelLogoutURI); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := s.validateSubjectType(&client); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := validateResponseEncryption(&client); err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if err := setTokenEndpointAuthMethod(&client, input.TokenEndpointAuthMethod); err != nil {
+		return model.OidcClient{}, err
+	}
 
 	if err := s.db.Save(&client).Error; err != nil {
 		return model.OidcClient{}, err
@@ -268,11 +1203,14 @@ func (s *OidcService) DeleteClient(clientID string) error {
 		return err
 	}
 
-	if err := s.db.Delete(&client).Error; err != nil {
-		return err
-	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Revoke the refresh tokens that were issued to the client
+		if err := tx.Delete(&model.OidcRefreshToken{}, "client_id = ?", client.ID).Error; err != nil {
+			return err
+		}
 
-	return nil
+		return tx.Delete(&client).Error
+	})
 }
 
 func (s *OidcService) CreateClientSecret(clientID string) (string, error) {
@@ -281,17 +1219,11 @@ func (s *OidcService) CreateClientSecret(clientID string) (string, error) {
 		return "", err
 	}
 
-	clientSecret, err := utils.GenerateRandomAlphanumericString(32)
-	if err != nil {
-		return "", err
-	}
-
-	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+	clientSecret, err := setClientSecret(&client)
 	if err != nil {
 		return "", err
 	}
 
-	client.Secret = string(hashedSecret)
 	if err := s.db.Save(&client).Error; err != nil {
 		return "", err
 	}
@@ -299,6 +1231,8 @@ func (s *OidcService) CreateClientSecret(clientID string) (string, error) {
 	return clientSecret, nil
 }
 
+// GetClientLogo returns the path and the MIME type of the uploaded logo. If the client only has a logo URL,
+// the URL is returned as the path and the MIME type is empty.
 func (s *OidcService) GetClientLogo(clientID string) (string, string, error) {
 	var client model.OidcClient
 	if err := s.db.First(&client, "id = ?", clientID).Error; err != nil {
@@ -363,6 +1297,7 @@ func (s *OidcService) DeleteClientLogo(clientID string) error {
 	}
 
 	client.ImageType = nil
+	client.LogoURI = nil
 	if err := s.db.Save(&client).Error; err != nil {
 		return err
 	}
@@ -370,67 +1305,87 @@ func (s *OidcService) DeleteClientLogo(clientID string) error {
 	return nil
 }
 
-func (s *OidcService) GetUserClaimsForClient(userID string, clientID string) (map[string]interface{}, error) {
+// GetUserClaimsForClient returns the claims of the user for the userinfo response or the ID token. The claims are
+// granted by the scope of the authorization or requested individually with the claims request parameter.
+func (s *OidcService) GetUserClaimsForClient(userID string, clientID string, target string) (map[string]interface{}, error) {
 	var authorizedOidcClient model.UserAuthorizedOidcClient
-	if err := s.db.Preload("User.UserGroups").First(&authorizedOidcClient, "user_id = ? AND client_id = ?", userID, clientID).Error; err != nil {
+	if err := s.db.Preload("User.UserGroups").Preload("Client").First(&authorizedOidcClient, "user_id = ? AND client_id = ?", userID, clientID).Error; err != nil {
 		return nil, err
 	}
 
 	user := authorizedOidcClient.User
 	scope := authorizedOidcClient.Scope
 
-	claims := map[string]interface{}{
-		"sub": user.ID,
+	subject, err := s.subjectForClient(authorizedOidcClient.Client, user.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.Contains(scope, "email") {
-		claims["email"] = user.Email
-		claims["email_verified"] = s.appConfigService.DbConfig.EmailsVerified.Value == "true"
+	requestedClaims, err := parseClaimsRequest(authorizedOidcClient.Claims)
+	if err != nil {
+		return nil, err
 	}
+	targetClaims := requestedClaims.forTarget(target)
 
-	if strings.Contains(scope, "groups") {
-		userGroups := make([]string, len(user.UserGroups))
-		for i, group := range user.UserGroups {
-			userGroups[i] = group.Name
-		}
-		claims["groups"] = userGroups
+	claims := map[string]interface{}{
+		"sub": subject,
 	}
 
-	profileClaims := map[string]interface{}{
-		"given_name":         user.FirstName,
-		"family_name":        user.LastName,
-		"name":               user.FullName(),
-		"preferred_username": user.Username,
+	grantedClaims, err := s.grantedClaims(scope)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.Contains(scope, "profile") {
-		// Add profile claims
-		for k, v := range profileClaims {
-			claims[k] = v
+	// addClaim adds the claim if it is granted by the scope or was requested with the claims parameter.
+	// Essential claims are treated like voluntary ones because the claims the user doesn't have can't be returned either way.
+	addClaim := func(granted bool, name string, value interface{}) {
+		request, requested := targetClaims[name]
+		if !granted && !requested {
+			return
+		}
+		if !request.matches(value) {
+			// Claims requested with a value are only returned if the value of the user matches
+			return
 		}
+		claims[name] = value
+	}
 
-		// Add custom claims
+	// Custom claims are added first, so that they can't overwrite the standard claims
+	if len(grantedClaims) > 0 || len(targetClaims) > 0 {
 		customClaims, err := s.customClaimService.GetCustomClaimsForUserWithUserGroups(userID)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, customClaim := range customClaims {
+			granted := hasScope(scope, "profile") || grantedClaims[customClaim.Key]
+
 			// The value of the custom claim can be a JSON object or a string
 			var jsonValue interface{}
 			json.Unmarshal([]byte(customClaim.Value), &jsonValue)
 			if jsonValue != nil {
 				// It's JSON so we store it as an object
-				claims[customClaim.Key] = jsonValue
+				addClaim(granted, customClaim.Key, jsonValue)
 			} else {
 				// Marshalling failed, so we store it as a string
-				claims[customClaim.Key] = customClaim.Value
+				addClaim(granted, customClaim.Key, customClaim.Value)
 			}
 		}
 	}
-	if strings.Contains(scope, "email") {
-		claims["email"] = user.Email
+
+	addClaim(grantedClaims["email"], "email", user.Email)
+	addClaim(grantedClaims["email_verified"], "email_verified", s.appConfigService.DbConfig.EmailsVerified.Value == "true")
+
+	userGroups := make([]string, len(user.UserGroups))
+	for i, group := range user.UserGroups {
+		userGroups[i] = group.Name
 	}
+	addClaim(grantedClaims["groups"], "groups", userGroups)
+
+	addClaim(grantedClaims["given_name"], "given_name", user.FirstName)
+	addClaim(grantedClaims["family_name"], "family_name", user.LastName)
+	addClaim(grantedClaims["name"], "name", user.FullName())
+	addClaim(grantedClaims["preferred_username"], "preferred_username", user.Username)
 
 	return claims, nil
 }
@@ -462,47 +1417,81 @@ func (s *OidcService) UpdateAllowedUserGroups(id string, input dto.OidcUpdateAll
 	return client, nil
 }
 
-// ValidateEndSession returns the logout callback URL for the client if all the validations pass
-func (s *OidcService) ValidateEndSession(input dto.OidcLogoutDto, userID string) (string, error) {
-	// If no ID token hint is provided, return an error
-	if input.IdTokenHint == "" {
-		return "", &common.TokenInvalidError{}
+// getIDTokenClaims returns the claims of the user for an ID token, including the ID of the session of the user at the client.
+// Tokens that aren't issued in a session, like those of the device flow, or whose session has ended don't have a sid.
+func (s *OidcService) getIDTokenClaims(userID, clientID, sessionID string) (map[string]interface{}, error) {
+	claims, err := s.GetUserClaimsForClient(userID, clientID, ClaimsTargetIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID == "" {
+		return claims, nil
 	}
 
-	// If the ID token hint is provided, verify the ID token
-	claims, err := s.jwtService.VerifyIdToken(input.IdTokenHint)
+	var session model.OidcSession
+	err = s.db.First(&session, "id = ? AND user_id = ? AND client_id = ?", sessionID, userID, clientID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return claims, nil
+	}
 	if err != nil {
-		return "", &common.TokenInvalidError{}
+		return nil, err
 	}
+	claims["sid"] = session.ID
 
-	// If the client ID is provided check if the client ID in the ID token matches the client ID in the request
-	if input.ClientId != "" && claims.Audience[0] != input.ClientId {
-		return "", &common.OidcClientIdNotMatchingError{}
+	if session.AuthTime != nil {
+		claims["auth_time"] = session.AuthTime.ToTime().Unix()
+		claims["acr"] = authenticationContextClass(session.Amr)
+		if len(session.Amr) > 0 {
+			claims["amr"] = session.Amr
+		}
 	}
 
-	clientId := claims.Audience[0]
+	return claims, nil
+}
 
-	// Check if the user has authorized the client before
-	var userAuthorizedOIDCClient model.UserAuthorizedOidcClient
-	if err := s.db.Preload("Client").First(&userAuthorizedOIDCClient, "client_id = ? AND user_id = ?", clientId, userID).Error; err != nil {
-		return "", &common.OidcMissingAuthorizationError{}
+// authenticationContextClass returns the acr of an authentication with the given methods
+func authenticationContextClass(authenticationMethods []string) string {
+	if slices.Contains(authenticationMethods, "hwk") {
+		return AcrValues[0]
 	}
+	return AcrValues[1]
+}
+
+// ListAuthorizedClients returns the clients the user has authorized, the most recently authorized first
+func (s *OidcService) ListAuthorizedClients(userID string) ([]model.UserAuthorizedOidcClient, error) {
+	var authorizedClients []model.UserAuthorizedOidcClient
+	err := s.db.Preload("Client").
+		Where("user_id = ?", userID).
+		Order("last_authorized_at DESC").
+		Find(&authorizedClients).Error
+	return authorizedClients, err
+}
 
-	// If the client has no logout callback URLs, return an error
-	if len(userAuthorizedOIDCClient.Client.LogoutCallbackURLs) == 0 {
-		return "", &common.OidcNoCallbackURLError{}
+// RevokeAuthorizedClient deletes the authorization of the client by the user and the refresh tokens the client holds for
+// the user, so that the user has to consent again the next time the client requests access
+func (s *OidcService) RevokeAuthorizedClient(userID, clientID, ipAddress, userAgent string) error {
+	var authorizedClient model.UserAuthorizedOidcClient
+	if err := s.db.Preload("Client").First(&authorizedClient, "user_id = ? AND client_id = ?", userID, clientID).Error; err != nil {
+		return err
 	}
 
-	callbackURL, err := s.getCallbackURL(userAuthorizedOIDCClient.Client.LogoutCallbackURLs, input.PostLogoutRedirectUri)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.OidcRefreshToken{}, "user_id = ? AND client_id = ?", userID, clientID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.UserAuthorizedOidcClient{}, "user_id = ? AND client_id = ?", userID, clientID).Error
+	})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return callbackURL, nil
+	s.auditLogService.Create(model.AuditLogEventClientAuthorizationRevoked, ipAddress, userAgent, userID, model.AuditLogData{"clientName": authorizedClient.Client.Name})
 
+	return nil
 }
 
-func (s *OidcService) createAuthorizationCode(clientID string, userID string, scope string, nonce string, codeChallenge string, codeChallengeMethod string) (string, error) {
+func (s *OidcService) createAuthorizationCode(client model.OidcClient, userID, sessionID string, scope string, resources []string, nonce string, codeChallenge string, codeChallengeMethod string) (string, error) {
 	randomString, err := utils.GenerateRandomAlphanumericString(32)
 	if err != nil {
 		return "", err
@@ -511,11 +1500,13 @@ func (s *OidcService) createAuthorizationCode(clientID string, userID string, sc
 	codeChallengeMethodSha256 := strings.ToUpper(codeChallengeMethod) == "S256"
 
 	oidcAuthorizationCode := model.OidcAuthorizationCode{
-		ExpiresAt:                 datatype.DateTime(time.Now().Add(15 * time.Minute)),
+		ExpiresAt:                 datatype.DateTime(time.Now().Add(s.tokenLifetimes(client).authorizationCode)),
 		Code:                      randomString,
-		ClientID:                  clientID,
+		ClientID:                  client.ID,
 		UserID:                    userID,
+		SessionID:                 sessionID,
 		Scope:                     scope,
+		Resources:                 resources,
 		Nonce:                     nonce,
 		CodeChallenge:             &codeChallenge,
 		CodeChallengeMethodSha256: &codeChallengeMethodSha256,
@@ -528,6 +1519,62 @@ func (s *OidcService) createAuthorizationCode(clientID string, userID string, sc
 	return randomString, nil
 }
 
+// tokenLifetimes are the lifetimes of the tokens and authorization codes issued to a client
+type tokenLifetimes struct {
+	idToken           time.Duration
+	accessToken       time.Duration
+	refreshToken      time.Duration
+	authorizationCode time.Duration
+}
+
+// tokenLifetimes returns the lifetimes the client is configured with. Lifetimes the client doesn't set fall back to the instance-wide defaults.
+func (s *OidcService) tokenLifetimes(client model.OidcClient) tokenLifetimes {
+	lifetime := func(clientLifetime int, defaultLifetime model.AppConfigVariable) time.Duration {
+		if clientLifetime == 0 {
+			clientLifetime, _ = strconv.Atoi(defaultLifetime.Value)
+		}
+		return time.Duration(clientLifetime) * time.Minute
+	}
+
+	config := s.appConfigService.DbConfig
+	return tokenLifetimes{
+		idToken:           lifetime(client.IDTokenLifetime, config.IDTokenLifetime),
+		accessToken:       lifetime(client.AccessTokenLifetime, config.AccessTokenLifetime),
+		refreshToken:      lifetime(client.RefreshTokenLifetime, config.RefreshTokenLifetime),
+		authorizationCode: lifetime(client.AuthorizationCodeLifetime, config.AuthorizationCodeLifetime),
+	}
+}
+
+// createRefreshToken stores a new refresh token. If no family ID is provided, a new token family is started.
+func (s *OidcService) createRefreshToken(tx *gorm.DB, client model.OidcClient, userID, sessionID, scope string, resources []string, familyID, dpopJkt string) (string, error) {
+	randomString, err := utils.GenerateRandomAlphanumericString(64)
+	if err != nil {
+		return "", err
+	}
+
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+
+	refreshToken := model.OidcRefreshToken{
+		ExpiresAt: datatype.DateTime(time.Now().Add(s.tokenLifetimes(client).refreshToken)),
+		Token:     utils.CreateSha256Hash(randomString),
+		FamilyID:  familyID,
+		Scope:     scope,
+		Resources: resources,
+		DpopJkt:   dpopJkt,
+		UserID:    userID,
+		SessionID: sessionID,
+		ClientID:  client.ID,
+	}
+
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return "", err
+	}
+
+	return randomString, nil
+}
+
 func (s *OidcService) validateCodeVerifier(codeVerifier, codeChallenge string, codeChallengeMethodSha256 bool) bool {
 	if codeVerifier == "" || codeChallenge == "" {
 		return false
@@ -548,13 +1595,21 @@ func (s *OidcService) validateCodeVerifier(codeVerifier, codeChallenge string, c
 	return encodedVerifierHash == codeChallenge
 }
 
-func (s *OidcService) getCallbackURL(urls []string, inputCallbackURL string) (callbackURL string, err error) {
+func (s *OidcService) getCallbackURL(client model.OidcClient, urls []string, inputCallbackURL string) (callbackURL string, err error) {
 	if inputCallbackURL == "" {
 		return urls[0], nil
 	}
 
+	// The callback URLs of dynamically registered clients don't support wildcards and have to match exactly
+	if isDynamicallyRegistered(client) {
+		if slices.Contains(urls, inputCallbackURL) {
+			return inputCallbackURL, nil
+		}
+		return "", &common.OidcInvalidCallbackURLError{}
+	}
+
 	for _, callbackPattern := range urls {
-		regexPattern := strings.ReplaceAll(regexp.QuoteMeta(callbackPattern), `\*`, ".*") + "$"
+		regexPattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(callbackPattern), `\*`, ".*") + "$"
 		matched, err := regexp.MatchString(regexPattern, inputCallbackURL)
 		if err != nil {
 			return "", err
@@ -566,3 +1621,191 @@ func (s *OidcService) getCallbackURL(urls []string, inputCallbackURL string) (ca
 
 	return "", &common.OidcInvalidCallbackURLError{}
 }
+
+// hasScope checks if the space separated scope string contains the given scope
+func hasScope(scope, name string) bool {
+	return slices.Contains(strings.Fields(scope), name)
+}
+
+// containsScopes checks if the space separated scope string contains all scopes of the requested scope string
+func containsScopes(scope, requested string) bool {
+	for _, name := range strings.Fields(requested) {
+		if !hasScope(scope, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeScopes returns the space separated union of two scope strings, keeping the order of the first one
+func mergeScopes(scope, other string) string {
+	scopes := strings.Fields(scope)
+	for _, name := range strings.Fields(other) {
+		if !slices.Contains(scopes, name) {
+			scopes = append(scopes, name)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+// intersectScopes returns the scopes of the first space separated scope string that are also contained in the second one
+func intersectScopes(scope, other string) string {
+	scopes := make([]string, 0)
+	for _, name := range strings.Fields(scope) {
+		if hasScope(other, name) {
+			scopes = append(scopes, name)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+// mergeClaimNames returns the union of two lists of claim names
+func mergeClaimNames(names, other []string) model.StringList {
+	merged := slices.Clone(names)
+	for _, name := range other {
+		if !slices.Contains(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// generateClientSecret returns a new client secret and its bcrypt hash
+func generateClientSecret() (string, string, error) {
+	clientSecret, err := utils.GenerateRandomAlphanumericString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+
+	return clientSecret, string(hashedSecret), nil
+}
+
+// setClientSecret generates a new secret for the client and returns it. The plain secret is
+// only kept for clients that use client_secret_jwt to verify the signature of their assertions.
+func setClientSecret(client *model.OidcClient) (string, error) {
+	clientSecret, hashedSecret, err := generateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	client.Secret = hashedSecret
+	client.JwtSecret = ""
+	if client.TokenEndpointAuthMethod == "client_secret_jwt" {
+		client.JwtSecret = clientSecret
+	}
+
+	return clientSecret, nil
+}
+
+// setTokenEndpointAuthMethod validates the token endpoint auth method and sets it on the client.
+// Public clients always use "none" and confidential clients default to "client_secret_basic".
+func setTokenEndpointAuthMethod(client *model.OidcClient, method string) error {
+	if client.IsPublic {
+		method = "none"
+	} else if method == "" || method == "none" {
+		method = "client_secret_basic"
+	}
+
+	if !slices.Contains(TokenEndpointAuthMethods, method) {
+		return &common.OidcUnsupportedTokenEndpointAuthMethodError{}
+	}
+
+	if (method == "private_key_jwt" || method == "self_signed_tls_client_auth") && client.Jwks == "" && client.JwksURI == "" {
+		return &common.OidcClientKeysRequiredError{Method: method}
+	}
+
+	if method == "tls_client_auth" && client.TlsClientAuthSubjectDN == "" {
+		return &common.OidcClientSubjectDNRequiredError{}
+	}
+
+	// The plain secret is no longer needed if the client doesn't use client_secret_jwt anymore
+	if method != "client_secret_jwt" {
+		client.JwtSecret = ""
+	}
+
+	client.TokenEndpointAuthMethod = method
+	return nil
+}
+
+// usesClientSecret returns true if the client authenticates itself with its secret
+func usesClientSecret(client model.OidcClient) bool {
+	switch client.TokenEndpointAuthMethod {
+	case "client_secret_basic", "client_secret_post", "client_secret_jwt":
+		return true
+	default:
+		return false
+	}
+}
+
+// isDynamicallyRegistered returns true if the client was registered through the registration endpoint. The metadata of
+// these clients is supplied by unauthenticated parties.
+func isDynamicallyRegistered(client model.OidcClient) bool {
+	return client.RegistrationAccessToken != nil
+}
+
+// getClientPublicKeys returns the keys registered inline or fetched from the JWKS URI of the client
+func (s *OidcService) getClientPublicKeys(client model.OidcClient) ([]utils.PublicJWK, error) {
+	if client.Jwks != "" {
+		return utils.ParseJWKSet([]byte(client.Jwks))
+	}
+
+	if client.JwksURI == "" {
+		return nil, errors.New("client has no registered keys")
+	}
+
+	// The keys are cached because every client assertion and request object would require a request otherwise
+	s.clientKeysCacheMutex.Lock()
+	cachedKeys, ok := s.clientKeysCache[client.ID]
+	s.clientKeysCacheMutex.Unlock()
+	if ok && cachedKeys.jwksURI == client.JwksURI && time.Now().Before(cachedKeys.expiresAt) {
+		return cachedKeys.keys, nil
+	}
+
+	body, err := fetchClientResource(client, client.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := utils.ParseJWKSet(body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clientKeysCacheMutex.Lock()
+	s.clientKeysCache[client.ID] = cachedClientKeys{jwksURI: client.JwksURI, keys: keys, expiresAt: time.Now().Add(clientKeysCacheDuration)}
+	s.clientKeysCacheMutex.Unlock()
+
+	return keys, nil
+}
+
+// fetchClientResource downloads a resource that is hosted by a client, like its JWKS or a request object
+func fetchClientResource(client model.OidcClient, uri string) ([]byte, error) {
+	// Limit the size to prevent clients from exhausting the memory
+	return utils.FetchURL(httpClientForClient(client), uri, 1<<20)
+}
+
+// httpClientForClient returns the HTTP client for requests to the URLs of a client. The URLs of dynamically
+// registered clients are supplied by unauthenticated parties, so only public addresses are requested for them.
+func httpClientForClient(client model.OidcClient) *http.Client {
+	if isDynamicallyRegistered(client) {
+		return utils.NewRestrictedHTTPClient(clientRequestTimeout)
+	}
+	return &http.Client{Timeout: clientRequestTimeout}
+}
+
+// validateClientKeys checks that the inline JWKS of a client can be parsed
+func validateClientKeys(jwks string) error {
+	if jwks == "" {
+		return nil
+	}
+
+	if _, err := utils.ParseJWKSet([]byte(jwks)); err != nil {
+		return &common.OidcInvalidJwksError{}
+	}
+
+	return nil
+}
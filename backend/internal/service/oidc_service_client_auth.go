@@ -0,0 +1,215 @@
+package service
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// authenticateClient returns the client if the provided credentials are valid. Public clients don't have a secret.
+func (s *OidcService) authenticateClient(credentials dto.OidcClientCredentialsDto) (model.OidcClient, error) {
+	if credentials.ClientAssertionType != "" || credentials.ClientAssertion != "" {
+		return s.authenticateClientWithAssertion(credentials)
+	}
+
+	var client model.OidcClient
+	if err := s.db.Preload("AllowedUserGroups").First(&client, "id = ?", credentials.ClientID).Error; err != nil {
+		return model.OidcClient{}, err
+	}
+
+	if client.IsPublic {
+		return client, nil
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case "tls_client_auth", "self_signed_tls_client_auth":
+		if err := s.verifyClientCertificate(client, credentials.ClientCertificate); err != nil {
+			return model.OidcClient{}, err
+		}
+		return client, nil
+	case "client_secret_jwt", "private_key_jwt":
+		// Clients that authenticate with a JWT must not fall back to sending their secret
+		return model.OidcClient{}, &common.OidcInvalidClientAuthMethodError{Method: client.TokenEndpointAuthMethod}
+	}
+
+	if credentials.ClientID == "" || credentials.ClientSecret == "" {
+		return model.OidcClient{}, &common.OidcMissingClientCredentialsError{}
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(client.Secret), []byte(credentials.ClientSecret))
+	if err != nil {
+		return model.OidcClient{}, &common.OidcClientSecretInvalidError{}
+	}
+
+	return client, nil
+}
+
+// authenticateClientWithAssertion authenticates the client with a JWT as defined by RFC 7523.
+// The assertion is either signed with a key of the client (private_key_jwt) or with its secret (client_secret_jwt).
+func (s *OidcService) authenticateClientWithAssertion(credentials dto.OidcClientCredentialsDto) (model.OidcClient, error) {
+	if credentials.ClientAssertionType != clientAssertionTypeJwtBearer || credentials.ClientAssertion == "" {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	// The client is identified by the subject of the assertion, which is verified below
+	var unverifiedClaims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(credentials.ClientAssertion, &unverifiedClaims); err != nil {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+	if credentials.ClientID != "" && credentials.ClientID != unverifiedClaims.Subject {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	var client model.OidcClient
+	if err := s.db.Preload("AllowedUserGroups").First(&client, "id = ?", unverifiedClaims.Subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+		}
+		return model.OidcClient{}, err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	var err error
+	switch client.TokenEndpointAuthMethod {
+	case "private_key_jwt":
+		err = s.verifyClientSignedJWT(client, credentials.ClientAssertion, claims, jwt.WithExpirationRequired())
+	case "client_secret_jwt":
+		err = verifyClientSecretJWT(client, credentials.ClientAssertion, claims)
+	default:
+		return model.OidcClient{}, &common.OidcInvalidClientAuthMethodError{Method: client.TokenEndpointAuthMethod}
+	}
+	if err != nil {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	if claims.Issuer != client.ID || claims.Subject != client.ID || claims.ID == "" {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	// The audience must be the issuer or one of the OAuth endpoints
+	audienceValid := slices.ContainsFunc(claims.Audience, func(audience string) bool {
+		return audience == common.EnvConfig.AppURL || strings.HasPrefix(audience, common.EnvConfig.AppURL+"/api/oidc/")
+	})
+	if !audienceValid {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	// Assertions that expire unreasonably far in the future are rejected as allowed by RFC 7523, so that the stored jti
+	// can't pile up
+	if claims.ExpiresAt.After(time.Now().Add(clientAssertionMaxLifetime)) {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	// Store the jti until the assertion expires to prevent it from being used twice
+	clientAssertion := model.OidcClientAssertion{
+		Jti:       claims.ID,
+		ExpiresAt: datatype.DateTime(claims.ExpiresAt.Time),
+		ClientID:  client.ID,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&clientAssertion)
+	if result.Error != nil {
+		return model.OidcClient{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.OidcClient{}, &common.OidcInvalidClientAssertionError{}
+	}
+
+	return client, nil
+}
+
+// verifyClientSignedJWT verifies that the JWT is signed with one of the keys of the client and parses it into the claims
+func (s *OidcService) verifyClientSignedJWT(client model.OidcClient, tokenString string, claims jwt.Claims, options ...jwt.ParserOption) error {
+	keys, err := s.getClientPublicKeys(client)
+	if err != nil {
+		return err
+	}
+
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		var verificationKeys []jwt.VerificationKey
+		for _, key := range keys {
+			if (kid != "" && key.Kid != kid) || !key.MatchesSigningAlgorithm(token.Method.Alg()) {
+				continue
+			}
+			publicKey, err := key.PublicKey()
+			if err != nil {
+				return nil, err
+			}
+			verificationKeys = append(verificationKeys, publicKey)
+		}
+
+		if len(verificationKeys) == 0 {
+			return nil, errors.New("no matching client key found")
+		}
+		return jwt.VerificationKeySet{Keys: verificationKeys}, nil
+	}, append(options, jwt.WithValidMethods(ClientSigningAlgorithms))...)
+
+	return err
+}
+
+// verifyClientSecretJWT verifies that the JWT is signed with the secret of the client and parses it into the claims
+func verifyClientSecretJWT(client model.OidcClient, tokenString string, claims jwt.Claims) error {
+	if client.JwtSecret == "" {
+		return errors.New("client has no secret for client_secret_jwt")
+	}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(client.JwtSecret), nil
+	}, jwt.WithValidMethods(ClientSecretSigningAlgorithms), jwt.WithExpirationRequired())
+
+	return err
+}
+
+// verifyClientCertificate authenticates the client with the certificate it presented over mutual TLS as defined by RFC 8705.
+// Certificates for tls_client_auth must be issued by a trusted CA and certificates for self_signed_tls_client_auth
+// must contain one of the registered keys of the client.
+func (s *OidcService) verifyClientCertificate(client model.OidcClient, certificate *x509.Certificate) error {
+	if certificate == nil {
+		return &common.OidcMissingClientCertificateError{}
+	}
+
+	if client.TokenEndpointAuthMethod == "self_signed_tls_client_auth" {
+		keys, err := s.getClientPublicKeys(client)
+		if err != nil {
+			return &common.OidcInvalidClientCertificateError{}
+		}
+
+		for _, key := range keys {
+			publicKey, err := key.PublicKey()
+			if err != nil {
+				continue
+			}
+			if comparableKey, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && comparableKey.Equal(certificate.PublicKey) {
+				return nil
+			}
+		}
+		return &common.OidcInvalidClientCertificateError{}
+	}
+
+	if s.clientCAs == nil {
+		return &common.OidcInvalidClientCertificateError{}
+	}
+
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:     s.clientCAs,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil || certificate.Subject.String() != client.TlsClientAuthSubjectDN {
+		return &common.OidcInvalidClientCertificateError{}
+	}
+
+	return nil
+}
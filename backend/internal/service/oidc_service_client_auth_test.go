@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+)
+
+// clientSecretJWTTestClient stores a client that authenticates with client_secret_jwt
+func clientSecretJWTTestClient(t *testing.T, s *OidcService) model.OidcClient {
+	t.Helper()
+
+	client := model.OidcClient{
+		Name:                    "Test",
+		CallbackURLs:            model.UrlList{"https://app.example/callback"},
+		TokenEndpointAuthMethod: "client_secret_jwt",
+		JwtSecret:               "client-secret-with-at-least-32-bytes",
+	}
+	if err := s.db.Create(&client).Error; err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func clientAssertionTestCredentials(t *testing.T, client model.OidcClient, expiresAt time.Time) dto.OidcClientCredentialsDto {
+	t.Helper()
+
+	claims := jwt.RegisteredClaims{
+		Issuer:    client.ID,
+		Subject:   client.ID,
+		Audience:  jwt.ClaimStrings{common.EnvConfig.AppURL + "/api/oidc/token"},
+		ID:        uuid.NewString(),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(client.JwtSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dto.OidcClientCredentialsDto{ClientAssertionType: clientAssertionTypeJwtBearer, ClientAssertion: assertion}
+}
+
+func TestAuthenticateClientWithAssertion(t *testing.T) {
+	s := newTestOidcService(t)
+	client := clientSecretJWTTestClient(t, s)
+
+	credentials := clientAssertionTestCredentials(t, client, time.Now().Add(time.Minute))
+	authenticatedClient, err := s.authenticateClient(credentials)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authenticatedClient.ID != client.ID {
+		t.Errorf("expected client '%s', got: '%s'", client.ID, authenticatedClient.ID)
+	}
+
+	// The same assertion can't be used twice
+	if _, err := s.authenticateClient(credentials); !errors.As(err, new(*common.OidcInvalidClientAssertionError)) {
+		t.Errorf("expected a replayed assertion to be rejected, got: %v", err)
+	}
+}
+
+func TestAuthenticateClientWithInvalidAssertionExpiry(t *testing.T) {
+	s := newTestOidcService(t)
+	client := clientSecretJWTTestClient(t, s)
+
+	var testData = map[string]time.Time{
+		"expired":             time.Now().Add(-time.Minute),
+		"expires too far out": time.Now().Add(clientAssertionMaxLifetime + time.Minute),
+	}
+	for name, expiresAt := range testData {
+		credentials := clientAssertionTestCredentials(t, client, expiresAt)
+		if _, err := s.authenticateClient(credentials); !errors.As(err, new(*common.OidcInvalidClientAssertionError)) {
+			t.Errorf("%s: expected the assertion to be rejected, got: %v", name, err)
+		}
+	}
+}
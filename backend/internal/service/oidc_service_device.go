@@ -0,0 +1,171 @@
+package service
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+)
+
+func (s *OidcService) createTokensFromDeviceCode(input dto.OidcCreateTokensDto) (dto.OidcTokenResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	var deviceCode model.OidcDeviceCode
+	if err := s.db.First(&deviceCode, "device_code = ? AND client_id = ?", input.DeviceCode, client.ID).Error; err != nil {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidDeviceCodeError{}
+	}
+
+	if deviceCode.ExpiresAt.ToTime().Before(time.Now()) {
+		return dto.OidcTokenResponseDto{}, &common.OidcDeviceCodeExpiredError{}
+	}
+
+	if !deviceCode.IsAuthorized {
+		// The client has to respect the polling interval, which is increased every time it polls too fast
+		pollingInterval := time.Duration(deviceCode.PollingInterval) * time.Second
+		pollingTooFast := deviceCode.LastPolledAt != nil && time.Since(deviceCode.LastPolledAt.ToTime()) < pollingInterval
+
+		now := datatype.DateTime(time.Now())
+		updates := map[string]interface{}{"last_polled_at": &now}
+		if pollingTooFast {
+			updates["polling_interval"] = int((pollingInterval + deviceCodeSlowDownIncrease).Seconds())
+		}
+		if err := s.db.Model(&deviceCode).Updates(updates).Error; err != nil {
+			return dto.OidcTokenResponseDto{}, err
+		}
+
+		if pollingTooFast {
+			return dto.OidcTokenResponseDto{}, &common.OidcSlowDownError{}
+		}
+		return dto.OidcTokenResponseDto{}, &common.OidcAuthorizationPendingError{}
+	}
+
+	confirmation, err := s.tokenConfirmation(client, input)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	resources, err := s.resolveResources(client.ID, nil, deviceCode.Scope)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+	audience, err := narrowResources(resources, input.Resource)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// The device code can only be exchanged once
+	result := s.db.Delete(&deviceCode)
+	if result.Error != nil {
+		return dto.OidcTokenResponseDto{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidDeviceCodeError{}
+	}
+
+	return s.createTokenResponseForUser(client, *deviceCode.UserID, "", deviceCode.Scope, resources, audience, "", confirmation)
+}
+
+// CreateDeviceAuthorization starts the device authorization flow and returns the device and user code
+func (s *OidcService) CreateDeviceAuthorization(input dto.OidcDeviceAuthorizationRequestDto) (dto.OidcDeviceAuthorizationResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcDeviceAuthorizationResponseDto{}, err
+	}
+
+	deviceCode, err := utils.GenerateRandomAlphanumericString(32)
+	if err != nil {
+		return dto.OidcDeviceAuthorizationResponseDto{}, err
+	}
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return dto.OidcDeviceAuthorizationResponseDto{}, err
+	}
+
+	oidcDeviceCode := model.OidcDeviceCode{
+		DeviceCode:      deviceCode,
+		UserCode:        userCode,
+		Scope:           input.Scope,
+		ExpiresAt:       datatype.DateTime(time.Now().Add(deviceCodeDuration)),
+		PollingInterval: int(deviceCodePollingInterval.Seconds()),
+		ClientID:        client.ID,
+	}
+
+	if err := s.db.Create(&oidcDeviceCode).Error; err != nil {
+		return dto.OidcDeviceAuthorizationResponseDto{}, err
+	}
+
+	formattedUserCode := userCode[:4] + "-" + userCode[4:]
+	verificationURI := common.EnvConfig.AppURL + "/device"
+
+	return dto.OidcDeviceAuthorizationResponseDto{
+		DeviceCode:              deviceCode,
+		UserCode:                formattedUserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?code=" + formattedUserCode,
+		ExpiresIn:               int(deviceCodeDuration.Seconds()),
+		Interval:                oidcDeviceCode.PollingInterval,
+	}, nil
+}
+
+// GetDeviceCode returns the pending device authorization for the user code
+func (s *OidcService) GetDeviceCode(userCode string) (model.OidcDeviceCode, error) {
+	var deviceCode model.OidcDeviceCode
+	err := s.db.Preload("Client").First(&deviceCode, "user_code = ? AND is_authorized = ?", normalizeUserCode(userCode), false).Error
+	if err != nil || deviceCode.ExpiresAt.ToTime().Before(time.Now()) {
+		return model.OidcDeviceCode{}, &common.OidcInvalidUserCodeError{}
+	}
+
+	return deviceCode, nil
+}
+
+// VerifyDeviceCode authorizes the device that requested the user code
+func (s *OidcService) VerifyDeviceCode(userCode, userID, ipAddress, userAgent string) error {
+	deviceCode, err := s.GetDeviceCode(userCode)
+	if err != nil {
+		return err
+	}
+
+	var client model.OidcClient
+	if err := s.db.Preload("AllowedUserGroups").First(&client, "id = ?", deviceCode.ClientID).Error; err != nil {
+		return err
+	}
+
+	if err := s.authorizeClient(client, userID, deviceCode.Scope, "", ipAddress, userAgent); err != nil {
+		return err
+	}
+
+	return s.db.Model(&deviceCode).Updates(map[string]interface{}{"user_id": userID, "is_authorized": true}).Error
+}
+
+// generateUserCode generates a user code for the device authorization flow.
+// The code only contains consonants to avoid ambiguous characters and words.
+func generateUserCode() (string, error) {
+	const charset = "BCDFGHJKLMNPQRSTVWXZ"
+
+	code := make([]byte, 8)
+	for i := range code {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = charset[num.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// normalizeUserCode removes the formatting of a user code that was entered by the user
+func normalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+	return strings.NewReplacer("-", "", " ", "").Replace(userCode)
+}
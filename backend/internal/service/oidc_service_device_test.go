@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+)
+
+func TestCreateTokensFromDeviceCodeWithSlowDown(t *testing.T) {
+	s := newTestOidcService(t)
+
+	client := model.OidcClient{Name: "Test", CallbackURLs: model.UrlList{"https://app.example/callback"}, IsPublic: true}
+	if err := s.db.Create(&client).Error; err != nil {
+		t.Fatal(err)
+	}
+	deviceCode := model.OidcDeviceCode{
+		DeviceCode:      "device-code",
+		UserCode:        "BCDFGHJK",
+		ExpiresAt:       datatype.DateTime(time.Now().Add(deviceCodeDuration)),
+		PollingInterval: int(deviceCodePollingInterval.Seconds()),
+		ClientID:        client.ID,
+	}
+	if err := s.db.Create(&deviceCode).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	input := dto.OidcCreateTokensDto{
+		GrantType:                "urn:ietf:params:oauth:grant-type:device_code",
+		DeviceCode:               deviceCode.DeviceCode,
+		OidcClientCredentialsDto: dto.OidcClientCredentialsDto{ClientID: client.ID},
+	}
+	if _, err := s.createTokensFromDeviceCode(input); !errors.As(err, new(*common.OidcAuthorizationPendingError)) {
+		t.Fatalf("expected authorization_pending, got: %v", err)
+	}
+
+	// Every poll that is too fast increases the interval by 5 seconds
+	for _, expectedInterval := range []int{10, 15} {
+		if _, err := s.createTokensFromDeviceCode(input); !errors.As(err, new(*common.OidcSlowDownError)) {
+			t.Fatalf("expected slow_down, got: %v", err)
+		}
+		if err := s.db.First(&deviceCode, "device_code = ?", deviceCode.DeviceCode).Error; err != nil {
+			t.Fatal(err)
+		}
+		if deviceCode.PollingInterval != expectedInterval {
+			t.Errorf("expected a polling interval of %d seconds, got: %d", expectedInterval, deviceCode.PollingInterval)
+		}
+	}
+}
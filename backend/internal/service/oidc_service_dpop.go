@@ -0,0 +1,203 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+	"gorm.io/gorm/clause"
+)
+
+// dpopProofClaims are the claims of a DPoP proof as defined by RFC 9449
+type dpopProofClaims struct {
+	jwt.RegisteredClaims
+	Htm   string `json:"htm"`
+	Htu   string `json:"htu"`
+	Nonce string `json:"nonce"`
+	Ath   string `json:"ath"`
+}
+
+// VerifyTokenConfirmation checks that the request is made with the key the access token is bound to.
+// DPoP proofs have to contain a nonce, so that proofs can't be created in advance for later use.
+func (s *OidcService) VerifyTokenConfirmation(claims *OauthAccessTokenJWTClaims, request dto.OidcAccessTokenRequestDto) error {
+	if claims.Cnf == nil {
+		return nil
+	}
+
+	if claims.Cnf.X5tS256 != "" && (request.ClientCertificate == nil || utils.CertificateThumbprint(request.ClientCertificate) != claims.Cnf.X5tS256) {
+		return &common.TokenInvalidError{}
+	}
+
+	// DPoP-bound tokens must not be accepted as bearer tokens
+	if claims.Cnf.Jkt != "" {
+		if !strings.EqualFold(request.AuthorizationScheme, "DPoP") {
+			return &common.TokenInvalidError{}
+		}
+
+		jkt, err := s.verifyDpopProof(request.Dpop, request.AccessToken, true)
+		var nonceErr *common.OidcUseDpopNonceError
+		if errors.As(err, &nonceErr) {
+			return &common.OidcDpopNonceRequiredError{}
+		}
+		if err != nil || jkt != claims.Cnf.Jkt {
+			return &common.TokenInvalidError{}
+		}
+	}
+
+	return nil
+}
+
+// CreateDpopNonce returns the current nonce clients have to include in their DPoP proofs
+func (s *OidcService) CreateDpopNonce() string {
+	return s.dpopNonce(time.Now().Unix() / int64(dpopNonceDuration.Seconds()))
+}
+
+// isValidDpopNonce returns true if the nonce was issued in the current or the previous period
+func (s *OidcService) isValidDpopNonce(nonce string) bool {
+	period := time.Now().Unix() / int64(dpopNonceDuration.Seconds())
+	return nonce != "" && (hmac.Equal([]byte(nonce), []byte(s.dpopNonce(period))) || hmac.Equal([]byte(nonce), []byte(s.dpopNonce(period-1))))
+}
+
+func (s *OidcService) dpopNonce(period int64) string {
+	mac := hmac.New(sha256.New, s.dpopNonceKey)
+	mac.Write([]byte(strconv.FormatInt(period, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDpopProof verifies the DPoP proof as defined by RFC 9449 and returns the JWK thumbprint of its key.
+// If an access token is passed, the proof must contain its hash.
+func (s *OidcService) verifyDpopProof(proof dto.OidcDpopProofDto, accessToken string, requireNonce bool) (string, error) {
+	var jwk utils.PublicJWK
+	claims := &dpopProofClaims{}
+	_, err := jwt.ParseWithClaims(proof.Proof, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, errors.New("invalid typ header")
+		}
+
+		header, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("missing jwk header")
+		}
+		// The header must only contain the public key
+		if _, ok := header["d"]; ok {
+			return nil, errors.New("jwk header contains a private key")
+		}
+
+		headerJSON, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(headerJSON, &jwk); err != nil {
+			return nil, err
+		}
+
+		if !jwk.MatchesSigningAlgorithm(token.Method.Alg()) {
+			return nil, errors.New("key doesn't match the algorithm")
+		}
+		return jwk.PublicKey()
+	}, jwt.WithValidMethods(ClientSigningAlgorithms))
+	if err != nil {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil || claims.Htm != proof.HttpMethod {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+
+	// The proof must have been created recently
+	issuedAt := claims.IssuedAt.Time
+	if time.Since(issuedAt) > dpopProofLifetime || time.Until(issuedAt) > time.Minute {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+
+	// The query and fragment of the URI are ignored
+	htu, err := url.Parse(claims.Htu)
+	if err != nil {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+	htu.RawQuery = ""
+	htu.Fragment = ""
+	if htu.String() != proof.HttpURL {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		if claims.Ath != base64.RawURLEncoding.EncodeToString(hash[:]) {
+			return "", &common.OidcInvalidDpopProofError{}
+		}
+	}
+
+	if requireNonce && !s.isValidDpopNonce(claims.Nonce) {
+		return "", &common.OidcUseDpopNonceError{}
+	}
+
+	// Store the jti until the proof expires to prevent it from being used twice
+	usedProof := model.OidcDpopProof{
+		Jti:       utils.CreateSha256Hash(claims.ID),
+		ExpiresAt: datatype.DateTime(issuedAt.Add(dpopProofLifetime)),
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&usedProof)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", &common.OidcInvalidDpopProofError{}
+	}
+
+	return jwk.Thumbprint()
+}
+
+// tokenConfirmation returns the confirmation the access tokens are bound to. Tokens of clients that authenticated
+// with mutual TLS are bound to their certificate (RFC 8705) and tokens requested with a DPoP proof to its key (RFC 9449).
+func (s *OidcService) tokenConfirmation(client model.OidcClient, input dto.OidcCreateTokensDto) (*dto.OidcTokenConfirmationDto, error) {
+	var confirmation dto.OidcTokenConfirmationDto
+
+	if input.ClientCertificate != nil && (client.TokenEndpointAuthMethod == "tls_client_auth" || client.TokenEndpointAuthMethod == "self_signed_tls_client_auth") {
+		confirmation.X5tS256 = utils.CertificateThumbprint(input.ClientCertificate)
+	}
+
+	if input.Dpop.Proof != "" {
+		jkt, err := s.verifyDpopProof(input.Dpop, "", true)
+		if err != nil {
+			return nil, err
+		}
+		confirmation.Jkt = jkt
+	} else if client.RequireDpop {
+		return nil, &common.OidcDpopProofRequiredError{}
+	}
+
+	if confirmation == (dto.OidcTokenConfirmationDto{}) {
+		return nil, nil
+	}
+	return &confirmation, nil
+}
+
+// refreshTokenJkt returns the DPoP key the refresh token is bound to. Refresh tokens of confidential
+// clients aren't bound because they can only be used with the client credentials anyway.
+func refreshTokenJkt(client model.OidcClient, confirmation *dto.OidcTokenConfirmationDto) string {
+	if !client.IsPublic || confirmation == nil {
+		return ""
+	}
+	return confirmation.Jkt
+}
+
+// tokenType returns the type of an access token with the given confirmation
+func tokenType(confirmation *dto.OidcTokenConfirmationDto) string {
+	if confirmation != nil && confirmation.Jkt != "" {
+		return "DPoP"
+	}
+	return "Bearer"
+}
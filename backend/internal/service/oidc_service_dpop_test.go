@@ -0,0 +1,233 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+)
+
+const dpopTestURL = "https://pocket-id.example/api/oidc/userinfo"
+
+// dpopTestProof signs a DPoP proof for a GET request of the test URL. The claims and header are passed to modify
+// them before the proof is signed.
+func dpopTestProof(t *testing.T, key *ecdsa.PrivateKey, modify func(header map[string]interface{}, claims jwt.MapClaims)) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"jti": uuid.NewString(),
+		"iat": time.Now().Unix(),
+		"htm": "GET",
+		"htu": dpopTestURL,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+	if modify != nil {
+		modify(token.Header, claims)
+	}
+
+	proof, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func dpopTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestVerifyDpopProof(t *testing.T) {
+	s := newTestOidcService(t)
+	key := dpopTestKey(t)
+
+	expected, err := utils.PublicJWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The query of the URI is ignored
+	proof := dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) {
+		claims["htu"] = dpopTestURL + "?query=ignored"
+	})
+	request := dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL}
+
+	jkt, err := s.verifyDpopProof(request, "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if jkt != expected {
+		t.Errorf("expected thumbprint '%s', got: '%s'", expected, jkt)
+	}
+
+	// The same proof can't be used twice
+	if _, err := s.verifyDpopProof(request, "", false); !errors.As(err, new(*common.OidcInvalidDpopProofError)) {
+		t.Errorf("expected a replayed proof to be rejected, got: %v", err)
+	}
+}
+
+type invalidDpopProofTestData struct {
+	name   string
+	modify func(header map[string]interface{}, claims jwt.MapClaims)
+}
+
+func TestVerifyDpopProofWithInvalidProofs(t *testing.T) {
+	s := newTestOidcService(t)
+	key := dpopTestKey(t)
+
+	var testData = []invalidDpopProofTestData{
+		{"wrong method", func(_ map[string]interface{}, claims jwt.MapClaims) { claims["htm"] = "POST" }},
+		{"wrong URI", func(_ map[string]interface{}, claims jwt.MapClaims) {
+			claims["htu"] = "https://other.example/api/oidc/userinfo"
+		}},
+		{"missing jti", func(_ map[string]interface{}, claims jwt.MapClaims) { delete(claims, "jti") }},
+		{"missing iat", func(_ map[string]interface{}, claims jwt.MapClaims) { delete(claims, "iat") }},
+		{"expired", func(_ map[string]interface{}, claims jwt.MapClaims) {
+			claims["iat"] = time.Now().Add(-dpopProofLifetime - time.Minute).Unix()
+		}},
+		{"issued in the future", func(_ map[string]interface{}, claims jwt.MapClaims) {
+			claims["iat"] = time.Now().Add(5 * time.Minute).Unix()
+		}},
+		{"wrong typ", func(header map[string]interface{}, _ jwt.MapClaims) { header["typ"] = "JWT" }},
+		{"missing jwk", func(header map[string]interface{}, _ jwt.MapClaims) { delete(header, "jwk") }},
+		{"private key", func(header map[string]interface{}, _ jwt.MapClaims) {
+			header["jwk"].(map[string]interface{})["d"] = base64.RawURLEncoding.EncodeToString(key.D.Bytes())
+		}},
+		{"other key", func(header map[string]interface{}, _ jwt.MapClaims) {
+			otherKey := dpopTestKey(t)
+			header["jwk"].(map[string]interface{})["x"] = base64.RawURLEncoding.EncodeToString(otherKey.X.FillBytes(make([]byte, 32)))
+			header["jwk"].(map[string]interface{})["y"] = base64.RawURLEncoding.EncodeToString(otherKey.Y.FillBytes(make([]byte, 32)))
+		}},
+		{"wrong access token hash", func(_ map[string]interface{}, claims jwt.MapClaims) { claims["ath"] = "invalid" }},
+	}
+
+	// Apart from the modification the proofs are valid, so that each one is rejected for its own reason
+	hash := sha256.Sum256([]byte("access-token"))
+	for _, data := range testData {
+		proof := dpopTestProof(t, key, func(header map[string]interface{}, claims jwt.MapClaims) {
+			claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+			data.modify(header, claims)
+		})
+		request := dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL}
+		if _, err := s.verifyDpopProof(request, "access-token", false); !errors.As(err, new(*common.OidcInvalidDpopProofError)) {
+			t.Errorf("%s: expected the proof to be rejected, got: %v", data.name, err)
+		}
+	}
+}
+
+func TestVerifyDpopProofWithAccessToken(t *testing.T) {
+	s := newTestOidcService(t)
+	key := dpopTestKey(t)
+
+	hash := sha256.Sum256([]byte("access-token"))
+	proof := dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) {
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	})
+
+	request := dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL}
+	if _, err := s.verifyDpopProof(request, "access-token", false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyDpopProofWithNonce(t *testing.T) {
+	s := newTestOidcService(t)
+	key := dpopTestKey(t)
+
+	var testData = map[string]string{
+		"missing nonce": "",
+		"invalid nonce": "invalid",
+		"old nonce":     s.dpopNonce(time.Now().Unix()/int64(dpopNonceDuration.Seconds()) - 2),
+	}
+	for name, nonce := range testData {
+		proof := dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) { claims["nonce"] = nonce })
+		request := dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL}
+		if _, err := s.verifyDpopProof(request, "", true); !errors.As(err, new(*common.OidcUseDpopNonceError)) {
+			t.Errorf("%s: expected use_dpop_nonce, got: %v", name, err)
+		}
+	}
+
+	proof := dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) { claims["nonce"] = s.CreateDpopNonce() })
+	request := dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL}
+	if _, err := s.verifyDpopProof(request, "", true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyTokenConfirmation(t *testing.T) {
+	s := newTestOidcService(t)
+	key := dpopTestKey(t)
+
+	jkt, err := s.verifyDpopProof(dto.OidcDpopProofDto{Proof: dpopTestProof(t, key, nil), HttpMethod: "GET", HttpURL: dpopTestURL}, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &OauthAccessTokenJWTClaims{Cnf: &dto.OidcTokenConfirmationDto{Jkt: jkt}}
+
+	hash := sha256.Sum256([]byte("access-token"))
+	validProof := func() string {
+		return dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) {
+			claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+			claims["nonce"] = s.CreateDpopNonce()
+		})
+	}
+	request := func(scheme, proof string) dto.OidcAccessTokenRequestDto {
+		return dto.OidcAccessTokenRequestDto{
+			AccessToken:         "access-token",
+			AuthorizationScheme: scheme,
+			Dpop:                dto.OidcDpopProofDto{Proof: proof, HttpMethod: "GET", HttpURL: dpopTestURL},
+		}
+	}
+
+	if err := s.VerifyTokenConfirmation(claims, request("DPoP", validProof())); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// DPoP-bound tokens can't be used as bearer tokens
+	if err := s.VerifyTokenConfirmation(claims, request("Bearer", validProof())); !errors.As(err, new(*common.TokenInvalidError)) {
+		t.Errorf("expected a bearer token to be rejected, got: %v", err)
+	}
+
+	// The proof has to be signed with the key the token is bound to
+	if err := s.VerifyTokenConfirmation(claims, request("DPoP", dpopTestProof(t, dpopTestKey(t), func(_ map[string]interface{}, claims jwt.MapClaims) {
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+		claims["nonce"] = s.CreateDpopNonce()
+	}))); !errors.As(err, new(*common.TokenInvalidError)) {
+		t.Errorf("expected a proof of another key to be rejected, got: %v", err)
+	}
+
+	// Resource servers signal a missing nonce with their own error
+	proofWithoutNonce := dpopTestProof(t, key, func(_ map[string]interface{}, claims jwt.MapClaims) {
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	})
+	if err := s.VerifyTokenConfirmation(claims, request("DPoP", proofWithoutNonce)); !errors.As(err, new(*common.OidcDpopNonceRequiredError)) {
+		t.Errorf("expected a proof without nonce to be rejected, got: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+)
+
+// generateIDToken signs the ID token and encrypts it if the client registered an encryption algorithm
+func (s *OidcService) generateIDToken(client model.OidcClient, userClaims map[string]interface{}, nonce string) (string, error) {
+	idToken, err := s.jwtService.GenerateIDToken(userClaims, client.ID, nonce, idTokenSigningAlgorithm(client), s.tokenLifetimes(client).idToken)
+	if err != nil || client.IDTokenEncryptedResponseAlg == "" {
+		return idToken, err
+	}
+
+	return s.encryptForClient(client, []byte(idToken), client.IDTokenEncryptedResponseAlg, client.IDTokenEncryptedResponseEnc, "JWT")
+}
+
+// idTokenSigningAlgorithm returns the algorithm the ID tokens of the client are signed with. RS256 is the default of
+// OpenID Connect Dynamic Client Registration.
+func idTokenSigningAlgorithm(client model.OidcClient) string {
+	if client.IDTokenSignedResponseAlg == "" {
+		return "RS256"
+	}
+	return client.IDTokenSignedResponseAlg
+}
+
+// CreateUserinfoJWT returns the claims as a signed and/or encrypted JWT if the client registered the algorithms for it.
+// If the client accepts plain JSON, an empty string is returned.
+func (s *OidcService) CreateUserinfoJWT(clientID string, claims map[string]interface{}) (string, error) {
+	var client model.OidcClient
+	if err := s.db.First(&client, "id = ?", clientID).Error; err != nil {
+		return "", err
+	}
+
+	if client.UserinfoSignedResponseAlg == "" && client.UserinfoEncryptedResponseAlg == "" {
+		return "", nil
+	}
+
+	var content []byte
+	var contentType string
+	if client.UserinfoSignedResponseAlg != "" {
+		signedUserinfo, err := s.jwtService.GenerateUserinfoToken(claims, client.ID)
+		if err != nil || client.UserinfoEncryptedResponseAlg == "" {
+			return signedUserinfo, err
+		}
+		// The signed response is encrypted as a nested JWT
+		content, contentType = []byte(signedUserinfo), "JWT"
+	} else {
+		var err error
+		if content, err = json.Marshal(claims); err != nil {
+			return "", err
+		}
+	}
+
+	return s.encryptForClient(client, content, client.UserinfoEncryptedResponseAlg, client.UserinfoEncryptedResponseEnc, contentType)
+}
+
+// encryptForClient encrypts the content as a JWE to the first key of the client that matches the algorithm
+func (s *OidcService) encryptForClient(client model.OidcClient, content []byte, alg, enc, contentType string) (string, error) {
+	keys, err := s.getClientPublicKeys(client)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range keys {
+		if key.MatchesEncryptionAlgorithm(alg) {
+			return utils.EncryptJWE(content, key, alg, enc, contentType)
+		}
+	}
+
+	return "", &common.OidcInvalidResponseEncryptionError{Message: "the client has no key for " + alg}
+}
+
+// validateResponseEncryption checks the algorithms the ID tokens and userinfo responses of the client are signed and encrypted with
+func validateResponseEncryption(client *model.OidcClient) error {
+	if client.IDTokenSignedResponseAlg != "" && !slices.Contains(IDTokenSigningAlgorithms, client.IDTokenSignedResponseAlg) {
+		return &common.OidcInvalidResponseEncryptionError{Message: "ID token signing algorithm " + client.IDTokenSignedResponseAlg + " is not supported"}
+	}
+	if client.UserinfoSignedResponseAlg != "" && !slices.Contains(UserinfoSigningAlgorithms, client.UserinfoSignedResponseAlg) {
+		return &common.OidcInvalidResponseEncryptionError{Message: "userinfo signing algorithm " + client.UserinfoSignedResponseAlg + " is not supported"}
+	}
+
+	if err := validateEncryptionAlgorithms(*client, client.IDTokenEncryptedResponseAlg, &client.IDTokenEncryptedResponseEnc); err != nil {
+		return err
+	}
+
+	return validateEncryptionAlgorithms(*client, client.UserinfoEncryptedResponseAlg, &client.UserinfoEncryptedResponseEnc)
+}
+
+// validateEncryptionAlgorithms checks a pair of JWE algorithms. The content encryption defaults to A128CBC-HS256 as defined by
+// OpenID Connect Dynamic Client Registration.
+func validateEncryptionAlgorithms(client model.OidcClient, alg string, enc *string) error {
+	if alg == "" {
+		if *enc != "" {
+			return &common.OidcInvalidResponseEncryptionError{Message: "a content encryption algorithm requires a key management algorithm"}
+		}
+		return nil
+	}
+
+	if !slices.Contains(utils.JWEKeyManagementAlgorithms, alg) {
+		return &common.OidcInvalidResponseEncryptionError{Message: "encryption algorithm " + alg + " is not supported"}
+	}
+
+	if *enc == "" {
+		*enc = "A128CBC-HS256"
+	}
+	if !slices.Contains(utils.JWEContentEncryptionAlgorithms, *enc) {
+		return &common.OidcInvalidResponseEncryptionError{Message: "content encryption algorithm " + *enc + " is not supported"}
+	}
+
+	if client.Jwks == "" && client.JwksURI == "" {
+		return &common.OidcInvalidResponseEncryptionError{Message: "encrypted responses require a JWKS or JWKS URI"}
+	}
+
+	// Keys behind a JWKS URI can change, so only inline keys are checked upfront
+	if client.Jwks != "" {
+		keys, _ := utils.ParseJWKSet([]byte(client.Jwks))
+		if !slices.ContainsFunc(keys, func(key utils.PublicJWK) bool { return key.MatchesEncryptionAlgorithm(alg) }) {
+			return &common.OidcInvalidResponseEncryptionError{Message: "the JWKS has no key for " + alg}
+		}
+	}
+
+	return nil
+}
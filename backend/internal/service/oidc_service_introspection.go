@@ -0,0 +1,85 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+)
+
+// IntrospectToken returns the state of an access or refresh token. Only confidential clients can introspect tokens.
+func (s *OidcService) IntrospectToken(input dto.OidcIntrospectDto) (dto.OidcIntrospectionResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcIntrospectionResponseDto{}, err
+	}
+
+	if client.IsPublic {
+		return dto.OidcIntrospectionResponseDto{}, &common.OidcUnauthorizedClientError{}
+	}
+
+	// The token type hint is only used to decide which token type is checked first
+	if input.TokenTypeHint == "refresh_token" {
+		if response, ok := s.introspectRefreshToken(input.Token, client); ok {
+			return response, nil
+		}
+		response, _ := s.introspectAccessToken(input.Token)
+		return response, nil
+	}
+
+	if response, ok := s.introspectAccessToken(input.Token); ok {
+		return response, nil
+	}
+	response, _ := s.introspectRefreshToken(input.Token, client)
+	return response, nil
+}
+
+func (s *OidcService) introspectAccessToken(token string) (dto.OidcIntrospectionResponseDto, bool) {
+	claims, err := s.VerifyOauthAccessToken(token)
+	if err != nil {
+		return dto.OidcIntrospectionResponseDto{Active: false}, false
+	}
+
+	return dto.OidcIntrospectionResponseDto{
+		Active:    true,
+		TokenType: tokenType(claims.Cnf),
+		Sub:       claims.Subject,
+		ClientID:  claims.GetClientID(),
+		Scope:     claims.Scope,
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       claims.IssuedAt.Unix(),
+		Iss:       claims.Issuer,
+		Cnf:       claims.Cnf,
+		Act:       claims.Act,
+	}, true
+}
+
+// introspectRefreshToken returns the state of a refresh token. Clients can only introspect their own refresh tokens.
+func (s *OidcService) introspectRefreshToken(token string, client model.OidcClient) (dto.OidcIntrospectionResponseDto, bool) {
+	var refreshToken model.OidcRefreshToken
+	if err := s.db.First(&refreshToken, "token = ? AND client_id = ?", utils.CreateSha256Hash(token), client.ID).Error; err != nil {
+		return dto.OidcIntrospectionResponseDto{Active: false}, false
+	}
+
+	if refreshToken.Used || refreshToken.ExpiresAt.ToTime().Before(time.Now()) {
+		return dto.OidcIntrospectionResponseDto{Active: false}, true
+	}
+
+	subject, err := s.subjectForClient(client, refreshToken.UserID)
+	if err != nil {
+		return dto.OidcIntrospectionResponseDto{Active: false}, true
+	}
+
+	return dto.OidcIntrospectionResponseDto{
+		Active:    true,
+		TokenType: "refresh_token",
+		Sub:       subject,
+		ClientID:  refreshToken.ClientID,
+		Scope:     refreshToken.Scope,
+		Exp:       refreshToken.ExpiresAt.ToTime().Unix(),
+		Iat:       refreshToken.CreatedAt.ToTime().Unix(),
+		Iss:       common.EnvConfig.AppURL,
+	}, true
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ValidateEndSession returns the logout callback URL for the client if all the validations pass
+func (s *OidcService) ValidateEndSession(input dto.OidcLogoutDto, userID string) (string, error) {
+	// If no ID token hint is provided, return an error
+	if input.IdTokenHint == "" {
+		return "", &common.TokenInvalidError{}
+	}
+
+	// If the ID token hint is provided, verify the ID token
+	claims, err := s.jwtService.VerifyIdToken(input.IdTokenHint)
+	if err != nil {
+		return "", &common.TokenInvalidError{}
+	}
+
+	// If the client ID is provided check if the client ID in the ID token matches the client ID in the request
+	if input.ClientId != "" && claims.Audience[0] != input.ClientId {
+		return "", &common.OidcClientIdNotMatchingError{}
+	}
+
+	clientId := claims.Audience[0]
+
+	// The ID token has to belong to the signed in user
+	hintUserID, err := s.UserIDFromSubject(claims.Subject)
+	if err != nil || hintUserID != userID {
+		return "", &common.TokenInvalidError{}
+	}
+
+	// Check if the user has authorized the client before
+	var userAuthorizedOIDCClient model.UserAuthorizedOidcClient
+	if err := s.db.Preload("Client").First(&userAuthorizedOIDCClient, "client_id = ? AND user_id = ?", clientId, userID).Error; err != nil {
+		return "", &common.OidcMissingAuthorizationError{}
+	}
+
+	// If the client has no logout callback URLs, return an error
+	if len(userAuthorizedOIDCClient.Client.LogoutCallbackURLs) == 0 {
+		return "", &common.OidcNoCallbackURLError{}
+	}
+
+	callbackURL, err := s.getCallbackURL(userAuthorizedOIDCClient.Client, userAuthorizedOIDCClient.Client.LogoutCallbackURLs, input.PostLogoutRedirectUri)
+	if err != nil {
+		return "", err
+	}
+
+	return callbackURL, nil
+
+}
+
+// LogoutUser ends the sessions at the clients the user signed in to during the login session and notifies the clients
+// that support back-channel logout. Sessions of other devices stay intact.
+// It returns the front-channel logout URLs the browser of the user has to load.
+func (s *OidcService) LogoutUser(userID, loginSessionID, ipAddress, userAgent string) ([]string, error) {
+	var sessions []model.OidcSession
+	err := s.db.Preload("Client").Find(&sessions, "user_id = ? AND login_session_id = ?", userID, loginSessionID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Delete(&model.OidcSession{}, "user_id = ? AND login_session_id = ?", userID, loginSessionID).Error; err != nil {
+		return nil, err
+	}
+
+	// The logout tokens are delivered in the background because clients might be slow or unavailable
+	for _, session := range sessions {
+		if session.Client.BackchannelLogoutURI != "" {
+			go s.sendBackchannelLogout(session.Client, userID, session.ID, ipAddress, userAgent)
+		}
+	}
+
+	// The front-channel logout URIs are loaded by the browser, so only the clients the user has a session at are included
+	frontchannelLogoutURLs := make([]string, 0)
+	for _, session := range sessions {
+		if session.Client.FrontchannelLogoutURI == "" {
+			continue
+		}
+
+		logoutURL, err := frontchannelLogoutURL(session.Client, session.ID)
+		if err != nil {
+			log.Printf("Failed to build front-channel logout URL for client %s: %v", session.ClientID, err)
+			continue
+		}
+		frontchannelLogoutURLs = append(frontchannelLogoutURLs, logoutURL)
+	}
+
+	return frontchannelLogoutURLs, nil
+}
+
+// RevokeUserSessions logs the user out of all clients and revokes the refresh tokens, so that the clients can't renew their sessions
+func (s *OidcService) RevokeUserSessions(userID, ipAddress, userAgent string) error {
+	var user model.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	var sessions []model.OidcSession
+	if err := s.db.Preload("Client").Find(&sessions, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	var authorizedClients []model.UserAuthorizedOidcClient
+	if err := s.db.Preload("Client").Find(&authorizedClients, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.OidcRefreshToken{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.OidcSession{}, "user_id = ?", userID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Front-channel logout requires the browser of the user, so only the back-channel logout applies here.
+	// Clients the user has no session at anymore still get a logout token without a sid, because they might
+	// have sessions based on the revoked refresh tokens.
+	clientsWithSession := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		clientsWithSession[session.ClientID] = true
+		if session.Client.BackchannelLogoutURI != "" {
+			go s.sendBackchannelLogout(session.Client, userID, session.ID, ipAddress, userAgent)
+		}
+	}
+	for _, authorizedClient := range authorizedClients {
+		if authorizedClient.Client.BackchannelLogoutURI != "" && !clientsWithSession[authorizedClient.ClientID] {
+			go s.sendBackchannelLogout(authorizedClient.Client, userID, "", ipAddress, userAgent)
+		}
+	}
+
+	return nil
+}
+
+// sendBackchannelLogout posts a logout token to the back-channel logout URI of the client and retries failed deliveries.
+// Each delivery is recorded in the audit log of the user.
+func (s *OidcService) sendBackchannelLogout(client model.OidcClient, userID, sessionID, ipAddress, userAgent string) {
+	subject, err := s.subjectForClient(client, userID)
+	if err != nil {
+		log.Printf("Failed to derive the subject for client %s: %v", client.ID, err)
+		return
+	}
+
+	logoutToken, err := s.jwtService.GenerateLogoutToken(client.ID, subject, sessionID)
+	if err != nil {
+		log.Printf("Failed to generate logout token for client %s: %v", client.ID, err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = postLogoutToken(httpClientForClient(client), client.BackchannelLogoutURI, logoutToken)
+		if err == nil || attempt == backchannelLogoutAttempts {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * backchannelLogoutRetryDelay)
+	}
+
+	event := model.AuditLogEventBackchannelLogout
+	data := model.AuditLogData{"clientName": client.Name, "clientId": client.ID}
+	if err != nil {
+		log.Printf("Failed to deliver logout token to client %s: %v", client.ID, err)
+		event = model.AuditLogEventBackchannelLogoutFailed
+		data["error"] = err.Error()
+	}
+	s.auditLogService.Create(event, ipAddress, userAgent, userID, data)
+}
+
+// postLogoutToken posts the logout token to the back-channel logout URI of a client
+func postLogoutToken(httpClient *http.Client, uri, logoutToken string) error {
+	// Clients must not redirect back-channel logout requests
+	noRedirectClient := *httpClient
+	noRedirectClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	res, err := noRedirectClient.PostForm(uri, url.Values{"logout_token": {logoutToken}})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// frontchannelLogoutURL returns the front-channel logout URI of the client with the issuer and the session ID
+// added if the client requires them
+func frontchannelLogoutURL(client model.OidcClient, sessionID string) (string, error) {
+	if !client.FrontchannelLogoutSessionRequired {
+		return client.FrontchannelLogoutURI, nil
+	}
+
+	logoutURL, err := url.Parse(client.FrontchannelLogoutURI)
+	if err != nil {
+		return "", err
+	}
+
+	query := logoutURL.Query()
+	query.Set("iss", common.EnvConfig.AppURL)
+	query.Set("sid", sessionID)
+	logoutURL.RawQuery = query.Encode()
+
+	return logoutURL.String(), nil
+}
+
+// validateLogoutURI checks that a back-channel or front-channel logout URI of a client is an absolute HTTP or HTTPS URL
+func validateLogoutURI(uri string) error {
+	if uri == "" {
+		return nil
+	}
+
+	parsedURI, err := url.Parse(uri)
+	if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || parsedURI.Host == "" || parsedURI.Fragment != "" {
+		return &common.OidcInvalidLogoutURIError{}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// subjectForClient returns the subject identifier of the user at the client. Pairwise clients get an identifier that is
+// derived from their sector identifier, so that clients of different sectors can't correlate the user.
+func (s *OidcService) subjectForClient(client model.OidcClient, userID string) (string, error) {
+	if client.SubjectType != "pairwise" {
+		return userID, nil
+	}
+
+	sectorIdentifier, err := sectorIdentifier(client)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.pairwiseSubjectSecret)
+	mac.Write([]byte(sectorIdentifier + " " + userID))
+	subject := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	// The mapping is stored because the subject of access tokens has to be resolved back to the user
+	pairwiseSubject := model.OidcPairwiseSubject{Subject: subject, SectorIdentifier: sectorIdentifier, UserID: userID}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&pairwiseSubject).Error; err != nil {
+		return "", err
+	}
+
+	return subject, nil
+}
+
+// UserIDFromSubject returns the ID of the user a subject identifier was issued for. Public subject identifiers are the user ID.
+func (s *OidcService) UserIDFromSubject(subject string) (string, error) {
+	var pairwiseSubject model.OidcPairwiseSubject
+	err := s.db.First(&pairwiseSubject, "subject = ?", subject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return subject, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return pairwiseSubject.UserID, nil
+}
+
+// sectorIdentifier returns the host of the sector identifier URI or, if it isn't set, of the callback URLs of the client
+func sectorIdentifier(client model.OidcClient) (string, error) {
+	sectorURL := client.SectorIdentifierURI
+	if sectorURL == "" && len(client.CallbackURLs) > 0 {
+		sectorURL = client.CallbackURLs[0]
+	}
+
+	parsedURL, err := url.Parse(sectorURL)
+	if err != nil || parsedURL.Host == "" {
+		return "", &common.OidcInvalidSubjectTypeError{Message: "the sector identifier of the client can't be determined"}
+	}
+
+	return parsedURL.Host, nil
+}
+
+// validateSubjectType checks that the subject identifiers of a pairwise client are derived from a single sector. The callback
+// URLs have to share the host or be listed in the JSON array the sector identifier URI returns.
+func (s *OidcService) validateSubjectType(client *model.OidcClient) error {
+	if client.SubjectType == "" {
+		client.SubjectType = "public"
+	}
+	if !slices.Contains(SubjectTypes, client.SubjectType) {
+		return &common.OidcInvalidSubjectTypeError{Message: "subject type " + client.SubjectType + " is not supported"}
+	}
+	if client.SubjectType != "pairwise" {
+		return nil
+	}
+
+	if client.SectorIdentifierURI == "" {
+		host, err := sectorIdentifier(*client)
+		if err != nil {
+			return err
+		}
+		for _, callbackURL := range client.CallbackURLs {
+			parsedURL, err := url.Parse(callbackURL)
+			if err != nil || parsedURL.Host != host {
+				return &common.OidcInvalidSubjectTypeError{Message: "the callback URLs of a pairwise client must have the same host unless a sector identifier URI is set"}
+			}
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(client.SectorIdentifierURI, "https://") {
+		return &common.OidcInvalidSubjectTypeError{Message: "sector identifier URI must be an HTTPS URL"}
+	}
+
+	body, err := fetchClientResource(*client, client.SectorIdentifierURI)
+	var sectorCallbackURLs []string
+	if err != nil || json.Unmarshal(body, &sectorCallbackURLs) != nil {
+		return &common.OidcInvalidSubjectTypeError{Message: "sector identifier URI must return a JSON array of callback URLs"}
+	}
+	for _, callbackURL := range client.CallbackURLs {
+		if !slices.Contains(sectorCallbackURLs, callbackURL) {
+			return &common.OidcInvalidSubjectTypeError{Message: "callback URL " + callbackURL + " isn't listed at the sector identifier URI"}
+		}
+	}
+
+	return nil
+}
+
+// loadOrGeneratePairwiseSubjectSecret loads the secret the pairwise subject identifiers are derived from.
+// It is generated once and stored next to the JWT keys because the identifiers must not change.
+func loadOrGeneratePairwiseSubjectSecret() ([]byte, error) {
+	secret, err := os.ReadFile(pairwiseSubjectSecretPath)
+	if err == nil {
+		return secret, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	secret = make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(pairwiseSubjectSecretPath), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(pairwiseSubjectSecretPath, secret, 0600); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+)
+
+const pairwiseTestUserID = "f4b89dc2-62fb-46bf-9f5f-c34f4eafe93e"
+
+func TestSubjectForClientWithPublicClient(t *testing.T) {
+	s := newTestOidcService(t)
+
+	client := model.OidcClient{SubjectType: "public", CallbackURLs: model.UrlList{"https://app.example/callback"}}
+	subject, err := s.subjectForClient(client, pairwiseTestUserID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if subject != pairwiseTestUserID {
+		t.Errorf("expected the user ID as subject, got: '%s'", subject)
+	}
+}
+
+func TestSubjectForClientWithPairwiseClient(t *testing.T) {
+	s := newTestOidcService(t)
+
+	subjectFor := func(client model.OidcClient) string {
+		t.Helper()
+		subject, err := s.subjectForClient(client, pairwiseTestUserID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return subject
+	}
+
+	subject := subjectFor(model.OidcClient{SubjectType: "pairwise", CallbackURLs: model.UrlList{"https://app.example/callback"}})
+	if subject == pairwiseTestUserID {
+		t.Errorf("expected the subject to differ from the user ID")
+	}
+
+	// Clients of the same sector get the same subject
+	if other := subjectFor(model.OidcClient{SubjectType: "pairwise", CallbackURLs: model.UrlList{"https://app.example/other"}}); other != subject {
+		t.Errorf("expected the same subject for the same host, got: '%s' and '%s'", subject, other)
+	}
+
+	// Clients of another sector get another subject
+	if other := subjectFor(model.OidcClient{SubjectType: "pairwise", CallbackURLs: model.UrlList{"https://other.example/callback"}}); other == subject {
+		t.Errorf("expected another subject for another host")
+	}
+
+	// The host of the sector identifier URI takes precedence over the callback URLs
+	if other := subjectFor(model.OidcClient{
+		SubjectType:         "pairwise",
+		SectorIdentifierURI: "https://app.example/sector.json",
+		CallbackURLs:        model.UrlList{"https://other.example/callback"},
+	}); other != subject {
+		t.Errorf("expected the subject of the sector identifier URI host, got: '%s'", other)
+	}
+
+	userID, err := s.UserIDFromSubject(subject)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userID != pairwiseTestUserID {
+		t.Errorf("expected the subject to resolve to '%s', got: '%s'", pairwiseTestUserID, userID)
+	}
+}
+
+func TestUserIDFromSubjectWithPublicSubject(t *testing.T) {
+	s := newTestOidcService(t)
+
+	userID, err := s.UserIDFromSubject(pairwiseTestUserID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userID != pairwiseTestUserID {
+		t.Errorf("expected a public subject to be the user ID, got: '%s'", userID)
+	}
+}
+
+func TestSectorIdentifierWithoutHost(t *testing.T) {
+	var testData = map[string]model.OidcClient{
+		"no callback URLs":  {SubjectType: "pairwise"},
+		"relative callback": {SubjectType: "pairwise", CallbackURLs: model.UrlList{"/callback"}},
+	}
+	for name, client := range testData {
+		if _, err := sectorIdentifier(client); !errors.As(err, new(*common.OidcInvalidSubjectTypeError)) {
+			t.Errorf("%s: expected an invalid subject type error, got: %v", name, err)
+		}
+	}
+}
+
+func TestValidateSubjectType(t *testing.T) {
+	s := newTestOidcService(t)
+
+	client := model.OidcClient{SubjectType: "pairwise", CallbackURLs: model.UrlList{"https://app.example/a", "https://app.example/b"}}
+	if err := s.validateSubjectType(&client); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Without a sector identifier URI the callback URLs have to share the host
+	client.CallbackURLs = append(client.CallbackURLs, "https://other.example/c")
+	if err := s.validateSubjectType(&client); !errors.As(err, new(*common.OidcInvalidSubjectTypeError)) {
+		t.Errorf("expected callback URLs of different hosts to be rejected, got: %v", err)
+	}
+
+	client = model.OidcClient{SubjectType: "pairwise", SectorIdentifierURI: "http://app.example/sector.json"}
+	if err := s.validateSubjectType(&client); !errors.As(err, new(*common.OidcInvalidSubjectTypeError)) {
+		t.Errorf("expected a sector identifier URI without HTTPS to be rejected, got: %v", err)
+	}
+}
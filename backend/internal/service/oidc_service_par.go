@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreatePushedAuthorizationRequest stores the authorization parameters of a client as defined by RFC 9126
+func (s *OidcService) CreatePushedAuthorizationRequest(input dto.OidcPushedAuthorizationRequestDto) (dto.OidcPushedAuthorizationResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	// A pushed authorization request can't reference another request
+	if input.RequestURI != "" {
+		return dto.OidcPushedAuthorizationResponseDto{}, &common.OidcInvalidRequestURIError{}
+	}
+
+	request := toAuthorizeOidcClientRequest(input.OidcAuthorizationRequestDto)
+	request.ClientID = client.ID
+	request, err = s.applyRequestObject(client, request)
+	if err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	if request.ResponseType == "" {
+		return dto.OidcPushedAuthorizationResponseDto{}, &common.OidcUnsupportedResponseTypeError{}
+	}
+	responseType, err := validateResponseType(client, request.ResponseType, request.Nonce)
+	if err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+	if _, err := resolveResponseMode(request.ResponseMode, responseType); err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+	if _, err := parsePrompt(request.Prompt); err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+	if _, err := parseClaimsRequest(request.Claims); err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	if request.Scope == "" {
+		return dto.OidcPushedAuthorizationResponseDto{}, &common.OidcInvalidScopeError{}
+	}
+	if _, err := s.resolveResources(client.ID, request.Resource, request.Scope); err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	if client.IsPublic && request.CodeChallenge == "" {
+		return dto.OidcPushedAuthorizationResponseDto{}, &common.OidcMissingCodeChallengeError{}
+	}
+
+	callbackURL, err := s.getCallbackURL(client, client.CallbackURLs, request.CallbackURL)
+	if err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	randomString, err := utils.GenerateRandomAlphanumericString(32)
+	if err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	pushedAuthorizationRequest := model.OidcPushedAuthorizationRequest{
+		RequestURI:          pushedAuthorizationRequestURIPrefix + randomString,
+		ResponseType:        request.ResponseType,
+		ResponseMode:        request.ResponseMode,
+		Scope:               request.Scope,
+		CallbackURL:         callbackURL,
+		State:               request.State,
+		Nonce:               request.Nonce,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		Prompt:              request.Prompt,
+		MaxAge:              request.MaxAge,
+		LoginHint:           request.LoginHint,
+		Claims:              request.Claims,
+		Resources:           request.Resource,
+		ExpiresAt:           datatype.DateTime(time.Now().Add(pushedAuthorizationRequestDuration)),
+		ClientID:            client.ID,
+	}
+
+	if err := s.db.Create(&pushedAuthorizationRequest).Error; err != nil {
+		return dto.OidcPushedAuthorizationResponseDto{}, err
+	}
+
+	return dto.OidcPushedAuthorizationResponseDto{
+		RequestURI: pushedAuthorizationRequest.RequestURI,
+		ExpiresIn:  int(pushedAuthorizationRequestDuration.Seconds()),
+	}, nil
+}
+
+// GetPushedAuthorizationRequest returns the pushed authorization request without consuming it
+func (s *OidcService) GetPushedAuthorizationRequest(clientID, requestURI string) (model.OidcPushedAuthorizationRequest, error) {
+	var pushedAuthorizationRequest model.OidcPushedAuthorizationRequest
+	err := s.db.
+		Where("request_uri = ? AND client_id = ? AND expires_at > ?", requestURI, clientID, datatype.DateTime(time.Now())).
+		First(&pushedAuthorizationRequest).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.OidcPushedAuthorizationRequest{}, &common.OidcInvalidRequestURIError{}
+		}
+		return model.OidcPushedAuthorizationRequest{}, err
+	}
+
+	return pushedAuthorizationRequest, nil
+}
+
+// consumePushedAuthorizationRequest deletes the pushed authorization request and returns its parameters.
+// A request URI can only be used once.
+func (s *OidcService) consumePushedAuthorizationRequest(clientID, requestURI string) (dto.AuthorizeOidcClientRequestDto, error) {
+	pushedAuthorizationRequest, err := s.GetPushedAuthorizationRequest(clientID, requestURI)
+	if err != nil {
+		return dto.AuthorizeOidcClientRequestDto{}, err
+	}
+
+	result := s.db.Delete(&model.OidcPushedAuthorizationRequest{}, "id = ?", pushedAuthorizationRequest.ID)
+	if result.Error != nil {
+		return dto.AuthorizeOidcClientRequestDto{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return dto.AuthorizeOidcClientRequestDto{}, &common.OidcInvalidRequestURIError{}
+	}
+
+	return toAuthorizeOidcClientRequestFromPushed(pushedAuthorizationRequest), nil
+}
+
+func toAuthorizeOidcClientRequestFromPushed(pushedAuthorizationRequest model.OidcPushedAuthorizationRequest) dto.AuthorizeOidcClientRequestDto {
+	return dto.AuthorizeOidcClientRequestDto{
+		ClientID:            pushedAuthorizationRequest.ClientID,
+		ResponseType:        pushedAuthorizationRequest.ResponseType,
+		ResponseMode:        pushedAuthorizationRequest.ResponseMode,
+		Scope:               pushedAuthorizationRequest.Scope,
+		CallbackURL:         pushedAuthorizationRequest.CallbackURL,
+		State:               pushedAuthorizationRequest.State,
+		Nonce:               pushedAuthorizationRequest.Nonce,
+		CodeChallenge:       pushedAuthorizationRequest.CodeChallenge,
+		CodeChallengeMethod: pushedAuthorizationRequest.CodeChallengeMethod,
+		Prompt:              pushedAuthorizationRequest.Prompt,
+		MaxAge:              pushedAuthorizationRequest.MaxAge,
+		LoginHint:           pushedAuthorizationRequest.LoginHint,
+		Claims:              pushedAuthorizationRequest.Claims,
+		Resource:            pushedAuthorizationRequest.Resources,
+	}
+}
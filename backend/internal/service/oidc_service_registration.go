@@ -0,0 +1,417 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+func (s *OidcService) ListInitialAccessTokens() ([]model.OidcInitialAccessToken, error) {
+	var initialAccessTokens []model.OidcInitialAccessToken
+	if err := s.db.Order("created_at DESC").Find(&initialAccessTokens).Error; err != nil {
+		return nil, err
+	}
+	return initialAccessTokens, nil
+}
+
+// CreateInitialAccessToken creates a token that allows to register clients dynamically. The token is only returned once.
+func (s *OidcService) CreateInitialAccessToken(input dto.OidcInitialAccessTokenCreateDto, userID string) (model.OidcInitialAccessToken, string, error) {
+	token, err := utils.GenerateRandomAlphanumericString(32)
+	if err != nil {
+		return model.OidcInitialAccessToken{}, "", err
+	}
+
+	initialAccessToken := model.OidcInitialAccessToken{
+		Token:       utils.CreateSha256Hash(token),
+		Description: input.Description,
+		ExpiresAt:   datatype.DateTime(input.ExpiresAt),
+		CreatedByID: userID,
+	}
+
+	if err := s.db.Create(&initialAccessToken).Error; err != nil {
+		return model.OidcInitialAccessToken{}, "", err
+	}
+
+	return initialAccessToken, token, nil
+}
+
+func (s *OidcService) DeleteInitialAccessToken(id string) error {
+	var initialAccessToken model.OidcInitialAccessToken
+	if err := s.db.First(&initialAccessToken, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	return s.db.Delete(&initialAccessToken).Error
+}
+
+// RegisterClient registers a client dynamically as defined by RFC 7591
+func (s *OidcService) RegisterClient(input dto.OidcClientRegistrationDto, initialAccessToken string) (dto.OidcClientRegistrationResponseDto, error) {
+	var token model.OidcInitialAccessToken
+	if err := s.db.First(&token, "token = ? AND expires_at > ?", utils.CreateSha256Hash(initialAccessToken), datatype.DateTime(time.Now())).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.OidcClientRegistrationResponseDto{}, &common.OidcInvalidInitialAccessTokenError{}
+		}
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	registrationAccessToken, err := utils.GenerateRandomAlphanumericString(32)
+	if err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+	hashedRegistrationAccessToken := utils.CreateSha256Hash(registrationAccessToken)
+
+	// The client is owned by the admin who created the initial access token. The registration access token is set
+	// upfront, so that the client is treated as dynamically registered while its metadata is validated.
+	client := model.OidcClient{CreatedByID: token.CreatedByID, RegistrationAccessToken: &hashedRegistrationAccessToken}
+	if err := s.applyClientRegistrationMetadata(&client, input); err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	// Clients that authenticate with their own keys or certificates don't need a secret
+	var clientSecret string
+	if usesClientSecret(client) {
+		clientSecret, err = setClientSecret(&client)
+		if err != nil {
+			return dto.OidcClientRegistrationResponseDto{}, err
+		}
+	}
+
+	logo, logoType, err := downloadRegisteredClientLogo(client, nil)
+	if err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	if err := s.db.Create(&client).Error; err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	if err := s.storeRegisteredClientLogo(&client, logo, logoType); err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	response := toClientRegistrationResponse(client)
+	response.ClientSecret = clientSecret
+	response.RegistrationAccessToken = registrationAccessToken
+
+	return response, nil
+}
+
+// GetRegisteredClient returns the metadata of a dynamically registered client as defined by RFC 7592
+func (s *OidcService) GetRegisteredClient(clientID, registrationAccessToken string) (dto.OidcClientRegistrationResponseDto, error) {
+	client, err := s.getRegisteredClient(clientID, registrationAccessToken)
+	if err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	return toClientRegistrationResponse(client), nil
+}
+
+// UpdateRegisteredClient replaces the metadata of a dynamically registered client as defined by RFC 7592
+func (s *OidcService) UpdateRegisteredClient(clientID, registrationAccessToken string, input dto.OidcClientRegistrationDto) (dto.OidcClientRegistrationResponseDto, error) {
+	client, err := s.getRegisteredClient(clientID, registrationAccessToken)
+	if err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	if input.ClientID != "" && input.ClientID != client.ID {
+		return dto.OidcClientRegistrationResponseDto{}, &common.OidcInvalidClientMetadataError{Message: "client_id doesn't match the registered client"}
+	}
+
+	previousLogoURI := client.LogoURI
+	if err := s.applyClientRegistrationMetadata(&client, input); err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	logo, logoType, err := downloadRegisteredClientLogo(client, previousLogoURI)
+	if err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	// A client that was public before doesn't have a secret yet and the plain secret
+	// is only stored for clients that use client_secret_jwt
+	var clientSecret string
+	needsSecret := client.Secret == "" || (client.TokenEndpointAuthMethod == "client_secret_jwt" && client.JwtSecret == "")
+	if usesClientSecret(client) && needsSecret {
+		clientSecret, err = setClientSecret(&client)
+		if err != nil {
+			return dto.OidcClientRegistrationResponseDto{}, err
+		}
+	}
+
+	if err := s.db.Save(&client).Error; err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	if err := s.storeRegisteredClientLogo(&client, logo, logoType); err != nil {
+		return dto.OidcClientRegistrationResponseDto{}, err
+	}
+
+	// The stored copy of the logo is removed together with the logo URI
+	if previousLogoURI != nil && client.LogoURI == nil && client.ImageType != nil {
+		if err := s.DeleteClientLogo(client.ID); err != nil {
+			return dto.OidcClientRegistrationResponseDto{}, err
+		}
+	}
+
+	response := toClientRegistrationResponse(client)
+	response.ClientSecret = clientSecret
+
+	return response, nil
+}
+
+// DeleteRegisteredClient deletes a dynamically registered client as defined by RFC 7592
+func (s *OidcService) DeleteRegisteredClient(clientID, registrationAccessToken string) error {
+	client, err := s.getRegisteredClient(clientID, registrationAccessToken)
+	if err != nil {
+		return err
+	}
+
+	return s.DeleteClient(client.ID)
+}
+
+func (s *OidcService) getRegisteredClient(clientID, registrationAccessToken string) (model.OidcClient, error) {
+	if registrationAccessToken == "" {
+		return model.OidcClient{}, &common.OidcInvalidRegistrationAccessTokenError{}
+	}
+
+	var client model.OidcClient
+	if err := s.db.First(&client, "id = ? AND registration_access_token = ?", clientID, utils.CreateSha256Hash(registrationAccessToken)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.OidcClient{}, &common.OidcInvalidRegistrationAccessTokenError{}
+		}
+		return model.OidcClient{}, err
+	}
+
+	return client, nil
+}
+
+// applyClientRegistrationMetadata validates the metadata of a dynamic client registration and maps it onto the client
+func (s *OidcService) applyClientRegistrationMetadata(client *model.OidcClient, input dto.OidcClientRegistrationDto) error {
+	if len(input.RedirectURIs) == 0 {
+		return &common.OidcInvalidClientMetadataError{Message: "redirect_uris is required"}
+	}
+
+	for _, uri := range slices.Concat(input.RedirectURIs, input.PostLogoutRedirectURIs) {
+		if err := validateRegisteredRedirectURI(uri); err != nil {
+			return err
+		}
+	}
+
+	client.IsPublic = input.TokenEndpointAuthMethod == "none"
+	if client.IsPublic {
+		client.ClientCredentialsScopes = nil
+		client.TokenExchangeAudiences = nil
+	}
+
+	name := input.ClientName
+	if name == "" {
+		// Fall back to the host of the first redirect URI
+		parsedURI, _ := url.Parse(input.RedirectURIs[0])
+		name = parsedURI.Host
+	}
+	if len(name) > 50 {
+		return &common.OidcInvalidClientMetadataError{Message: "client_name can't be longer than 50 characters"}
+	}
+
+	client.LogoURI = nil
+	if input.LogoURI != "" {
+		parsedURI, err := url.Parse(input.LogoURI)
+		if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || parsedURI.Host == "" {
+			return &common.OidcInvalidClientMetadataError{Message: "logo_uri must be a HTTP or HTTPS URL"}
+		}
+		client.LogoURI = &input.LogoURI
+	}
+
+	client.Name = name
+	client.CallbackURLs = input.RedirectURIs
+	client.LogoutCallbackURLs = input.PostLogoutRedirectURIs
+	client.PkceEnabled = client.IsPublic || client.PkceEnabled
+	client.RequirePar = input.RequirePar
+	client.RequireSignedRequest = input.RequireSignedRequest
+	client.RequestURIs = input.RequestURIs
+	if err := validateRequestURIs(client.RequestURIs); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+	client.RequireDpop = input.RequireDpop
+	client.JwksURI = input.JwksURI
+	client.TlsClientAuthSubjectDN = input.TlsClientAuthSubjectDN
+
+	client.BackchannelLogoutURI = input.BackchannelLogoutURI
+	if err := validateLogoutURI(client.BackchannelLogoutURI); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+
+	client.FrontchannelLogoutURI = input.FrontchannelLogoutURI
+	client.FrontchannelLogoutSessionRequired = input.FrontchannelLogoutSessionRequired
+	if err := validateLogoutURI(client.FrontchannelLogoutURI); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+
+	client.SubjectType = input.SubjectType
+	client.SectorIdentifierURI = input.SectorIdentifierURI
+	if err := s.validateSubjectType(client); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+
+	client.ImplicitFlowEnabled = false
+	for _, responseType := range input.ResponseTypes {
+		normalizedResponseType := normalizeResponseType(responseType)
+		if !slices.Contains(ResponseTypes, normalizedResponseType) {
+			return &common.OidcInvalidClientMetadataError{Message: "response type " + responseType + " is not supported"}
+		}
+		client.ImplicitFlowEnabled = client.ImplicitFlowEnabled || normalizedResponseType != "code"
+	}
+
+	client.Jwks = ""
+	if len(input.Jwks) > 0 && string(input.Jwks) != "null" {
+		client.Jwks = string(input.Jwks)
+		if err := validateClientKeys(client.Jwks); err != nil {
+			return &common.OidcInvalidClientMetadataError{Message: "jwks is invalid"}
+		}
+	}
+	if client.Jwks != "" && client.JwksURI != "" {
+		return &common.OidcInvalidClientMetadataError{Message: "jwks and jwks_uri can't be used together"}
+	}
+
+	client.IDTokenSignedResponseAlg = input.IDTokenSignedResponseAlg
+	client.IDTokenEncryptedResponseAlg = input.IDTokenEncryptedResponseAlg
+	client.IDTokenEncryptedResponseEnc = input.IDTokenEncryptedResponseEnc
+	client.UserinfoSignedResponseAlg = input.UserinfoSignedResponseAlg
+	client.UserinfoEncryptedResponseAlg = input.UserinfoEncryptedResponseAlg
+	client.UserinfoEncryptedResponseEnc = input.UserinfoEncryptedResponseEnc
+	if err := validateResponseEncryption(client); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+
+	if err := setTokenEndpointAuthMethod(client, input.TokenEndpointAuthMethod); err != nil {
+		return &common.OidcInvalidClientMetadataError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+// validateRegisteredRedirectURI checks a redirect URI of a dynamically registered client. Only HTTPS URIs and HTTP URIs
+// of loopback addresses for native apps (RFC 8252) are accepted, as other schemes like javascript: would be executed by
+// the browser. Wildcards aren't allowed because the redirect URIs of these clients have to match exactly.
+func validateRegisteredRedirectURI(uri string) error {
+	parsedURI, err := url.Parse(uri)
+	if err != nil || parsedURI.Host == "" || parsedURI.Fragment != "" || strings.Contains(uri, "*") {
+		return &common.OidcInvalidRedirectURIError{URI: uri}
+	}
+
+	if parsedURI.Scheme != "https" && (parsedURI.Scheme != "http" || !utils.IsLoopbackHost(parsedURI.Hostname())) {
+		return &common.OidcInvalidRedirectURIError{URI: uri}
+	}
+
+	return nil
+}
+
+// downloadRegisteredClientLogo downloads the logo of a dynamically registered client if its logo URI changed. The logo is
+// served from a stored copy because redirecting the browser to the URI would turn the logo endpoint into an open redirect.
+func downloadRegisteredClientLogo(client model.OidcClient, previousLogoURI *string) ([]byte, string, error) {
+	if client.LogoURI == nil || (previousLogoURI != nil && *previousLogoURI == *client.LogoURI) {
+		return nil, "", nil
+	}
+
+	logo, err := utils.FetchURL(utils.NewRestrictedHTTPClient(clientRequestTimeout), *client.LogoURI, 2<<20)
+	if err != nil {
+		return nil, "", &common.OidcInvalidClientMetadataError{Message: "logo_uri can't be downloaded"}
+	}
+
+	// The type is detected from the content because the logo is served with the type of its file extension.
+	// SVG images aren't accepted as they can contain scripts.
+	switch http.DetectContentType(logo) {
+	case "image/png":
+		return logo, "png", nil
+	case "image/jpeg":
+		return logo, "jpg", nil
+	case "image/x-icon":
+		return logo, "ico", nil
+	default:
+		return nil, "", &common.OidcInvalidClientMetadataError{Message: "logo_uri must be a PNG, JPEG or ICO image"}
+	}
+}
+
+// storeRegisteredClientLogo stores the downloaded logo of a dynamically registered client like an uploaded logo
+func (s *OidcService) storeRegisteredClientLogo(client *model.OidcClient, logo []byte, fileType string) error {
+	if logo == nil {
+		return nil
+	}
+
+	imagePath := fmt.Sprintf("%s/oidc-client-images/%s.%s", common.EnvConfig.UploadPath, client.ID, fileType)
+	if err := os.MkdirAll(filepath.Dir(imagePath), 0o750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(imagePath, logo, 0o600); err != nil {
+		return err
+	}
+
+	if client.ImageType != nil && *client.ImageType != fileType {
+		oldImagePath := fmt.Sprintf("%s/oidc-client-images/%s.%s", common.EnvConfig.UploadPath, client.ID, *client.ImageType)
+		if err := os.Remove(oldImagePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	client.ImageType = &fileType
+	return s.db.Model(client).Update("image_type", fileType).Error
+}
+
+func toClientRegistrationResponse(client model.OidcClient) dto.OidcClientRegistrationResponseDto {
+	var logoURI string
+	if client.LogoURI != nil {
+		logoURI = *client.LogoURI
+	}
+
+	return dto.OidcClientRegistrationResponseDto{
+		ClientID:                          client.ID,
+		ClientIDIssuedAt:                  client.CreatedAt.ToTime().Unix(),
+		RegistrationClientURI:             common.EnvConfig.AppURL + "/api/oidc/register/" + client.ID,
+		ClientName:                        client.Name,
+		RedirectURIs:                      client.CallbackURLs,
+		PostLogoutRedirectURIs:            client.LogoutCallbackURLs,
+		TokenEndpointAuthMethod:           client.TokenEndpointAuthMethod,
+		TlsClientAuthSubjectDN:            client.TlsClientAuthSubjectDN,
+		BackchannelLogoutURI:              client.BackchannelLogoutURI,
+		FrontchannelLogoutURI:             client.FrontchannelLogoutURI,
+		FrontchannelLogoutSessionRequired: client.FrontchannelLogoutSessionRequired,
+		SubjectType:                       client.SubjectType,
+		SectorIdentifierURI:               client.SectorIdentifierURI,
+		IDTokenSignedResponseAlg:          idTokenSigningAlgorithm(client),
+		IDTokenEncryptedResponseAlg:       client.IDTokenEncryptedResponseAlg,
+		IDTokenEncryptedResponseEnc:       client.IDTokenEncryptedResponseEnc,
+		UserinfoSignedResponseAlg:         client.UserinfoSignedResponseAlg,
+		UserinfoEncryptedResponseAlg:      client.UserinfoEncryptedResponseAlg,
+		UserinfoEncryptedResponseEnc:      client.UserinfoEncryptedResponseEnc,
+		LogoURI:                           logoURI,
+		RequirePar:                        client.RequirePar,
+		RequireSignedRequest:              client.RequireSignedRequest,
+		RequestURIs:                       client.RequestURIs,
+		RequireDpop:                       client.RequireDpop,
+		ResponseTypes:                     clientResponseTypes(client),
+		Jwks:                              json.RawMessage(client.Jwks),
+		JwksURI:                           client.JwksURI,
+	}
+}
+
+// clientResponseTypes returns the response types the client is allowed to use
+func clientResponseTypes(client model.OidcClient) []string {
+	if client.ImplicitFlowEnabled {
+		return ResponseTypes
+	}
+	return []string{"code"}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+)
+
+// requestObjectClaims are the claims of a signed request object as defined by RFC 9101
+type requestObjectClaims struct {
+	jwt.RegisteredClaims
+	ClientID            string `json:"client_id"`
+	ResponseType        string `json:"response_type"`
+	ResponseMode        string `json:"response_mode"`
+	Scope               string `json:"scope"`
+	RedirectURI         string `json:"redirect_uri"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Prompt              string `json:"prompt"`
+	MaxAge              *int   `json:"max_age"`
+	LoginHint           string `json:"login_hint"`
+	// Claims is a JSON object in request objects instead of a string
+	Claims json.RawMessage `json:"claims"`
+	// Resource is a single identifier or an array of identifiers
+	Resource jwt.ClaimStrings `json:"resource"`
+}
+
+// applyTo overwrites the parameters of the authorization request with the values of the request object
+func (c *requestObjectClaims) applyTo(input *dto.AuthorizeOidcClientRequestDto) {
+	overwrite := func(target *string, value string) {
+		if value != "" {
+			*target = value
+		}
+	}
+
+	overwrite(&input.ResponseType, c.ResponseType)
+	overwrite(&input.ResponseMode, c.ResponseMode)
+	overwrite(&input.Scope, c.Scope)
+	overwrite(&input.CallbackURL, c.RedirectURI)
+	overwrite(&input.State, c.State)
+	overwrite(&input.Nonce, c.Nonce)
+	overwrite(&input.CodeChallenge, c.CodeChallenge)
+	overwrite(&input.CodeChallengeMethod, c.CodeChallengeMethod)
+	overwrite(&input.Prompt, c.Prompt)
+	overwrite(&input.LoginHint, c.LoginHint)
+	if c.MaxAge != nil {
+		input.MaxAge = c.MaxAge
+	}
+	if len(c.Claims) > 0 && string(c.Claims) != "null" {
+		input.Claims = string(c.Claims)
+	}
+	if len(c.Resource) > 0 {
+		input.Resource = c.Resource
+	}
+}
+
+// ResolveAuthorizationRequest returns the effective parameters of an authorization request
+// without consuming a pushed authorization request
+func (s *OidcService) ResolveAuthorizationRequest(input dto.OidcAuthorizationRequestDto) (dto.AuthorizeOidcClientRequestDto, error) {
+	var client model.OidcClient
+	if err := s.db.First(&client, "id = ?", input.ClientID).Error; err != nil {
+		return dto.AuthorizeOidcClientRequestDto{}, err
+	}
+
+	return s.resolveAuthorizationRequest(client, toAuthorizeOidcClientRequest(input), false)
+}
+
+// resolveAuthorizationRequest returns the effective parameters of an authorization request. Pushed parameters replace
+// the input completely, while the values of a signed request object take precedence over the plain parameters.
+func (s *OidcService) resolveAuthorizationRequest(client model.OidcClient, input dto.AuthorizeOidcClientRequestDto, consume bool) (dto.AuthorizeOidcClientRequestDto, error) {
+	if strings.HasPrefix(input.RequestURI, pushedAuthorizationRequestURIPrefix) {
+		if consume {
+			return s.consumePushedAuthorizationRequest(client.ID, input.RequestURI)
+		}
+
+		pushedAuthorizationRequest, err := s.GetPushedAuthorizationRequest(client.ID, input.RequestURI)
+		if err != nil {
+			return dto.AuthorizeOidcClientRequestDto{}, err
+		}
+		return toAuthorizeOidcClientRequestFromPushed(pushedAuthorizationRequest), nil
+	}
+
+	if client.RequirePar {
+		return dto.AuthorizeOidcClientRequestDto{}, &common.OidcPushedAuthorizationRequestRequiredError{}
+	}
+
+	return s.applyRequestObject(client, input)
+}
+
+// applyRequestObject verifies the signed request object passed by value or by reference and
+// overwrites the plain parameters with its values
+func (s *OidcService) applyRequestObject(client model.OidcClient, input dto.AuthorizeOidcClientRequestDto) (dto.AuthorizeOidcClientRequestDto, error) {
+	requestObject := input.Request
+	if input.RequestURI != "" {
+		if requestObject != "" {
+			return dto.AuthorizeOidcClientRequestDto{}, &common.OidcInvalidRequestObjectError{}
+		}
+
+		// Only registered request URIs are fetched, so that the endpoint can't be used to send requests to arbitrary URLs
+		if !slices.Contains(client.RequestURIs, stripFragment(input.RequestURI)) {
+			return dto.AuthorizeOidcClientRequestDto{}, &common.OidcInvalidRequestURIError{}
+		}
+
+		body, err := fetchClientResource(client, input.RequestURI)
+		if err != nil {
+			return dto.AuthorizeOidcClientRequestDto{}, &common.OidcInvalidRequestURIError{}
+		}
+		requestObject = strings.TrimSpace(string(body))
+	}
+
+	if requestObject == "" {
+		if client.RequireSignedRequest {
+			return dto.AuthorizeOidcClientRequestDto{}, &common.OidcSignedRequestObjectRequiredError{}
+		}
+		return input, nil
+	}
+
+	// The request object must be issued by the client, intended for us and limited in time as required by RFC 9101
+	claims := &requestObjectClaims{}
+	err := s.verifyClientSignedJWT(client, requestObject, claims,
+		jwt.WithIssuer(client.ID),
+		jwt.WithAudience(common.EnvConfig.AppURL),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || (claims.ClientID != "" && claims.ClientID != client.ID) {
+		return dto.AuthorizeOidcClientRequestDto{}, &common.OidcInvalidRequestObjectError{}
+	}
+
+	claims.applyTo(&input)
+	input.Request = ""
+	input.RequestURI = ""
+
+	return input, nil
+}
+
+// validateRequestURIs checks that the request URIs of a client are absolute HTTPS URLs
+func validateRequestURIs(requestURIs []string) error {
+	for _, requestURI := range requestURIs {
+		parsedURI, err := url.Parse(requestURI)
+		if err != nil || parsedURI.Scheme != "https" || parsedURI.Host == "" || parsedURI.Fragment != "" {
+			return &common.OidcInvalidRequestURIsError{}
+		}
+	}
+
+	return nil
+}
+
+// stripFragment removes the fragment of a request URI. Clients can add a fragment to make the
+// server fetch a new version of the request object, so it isn't part of the registered URI.
+func stripFragment(uri string) string {
+	uri, _, _ = strings.Cut(uri, "#")
+	return uri
+}
+
+func toAuthorizeOidcClientRequest(input dto.OidcAuthorizationRequestDto) dto.AuthorizeOidcClientRequestDto {
+	return dto.AuthorizeOidcClientRequestDto{
+		ClientID:            input.ClientID,
+		ResponseType:        input.ResponseType,
+		ResponseMode:        input.ResponseMode,
+		Scope:               input.Scope,
+		CallbackURL:         input.RedirectURI,
+		State:               input.State,
+		Nonce:               input.Nonce,
+		CodeChallenge:       input.CodeChallenge,
+		CodeChallengeMethod: input.CodeChallengeMethod,
+		Prompt:              input.Prompt,
+		MaxAge:              input.MaxAge,
+		LoginHint:           input.LoginHint,
+		Claims:              input.Claims,
+		Resource:            input.Resource,
+		Request:             input.Request,
+		RequestURI:          input.RequestURI,
+	}
+}
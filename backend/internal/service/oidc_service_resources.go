@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+func (s *OidcService) ListResources() ([]model.OidcResource, error) {
+	var resources []model.OidcResource
+	if err := s.db.Preload("AllowedClients").Order("name").Find(&resources).Error; err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (s *OidcService) GetResource(id string) (model.OidcResource, error) {
+	var resource model.OidcResource
+	if err := s.db.Preload("AllowedClients").First(&resource, "id = ?", id).Error; err != nil {
+		return model.OidcResource{}, err
+	}
+	return resource, nil
+}
+
+// CreateResource creates an API resource. No client is allowed to request it until the allowed clients are set.
+func (s *OidcService) CreateResource(input dto.OidcResourceCreateDto) (model.OidcResource, error) {
+	resource := model.OidcResource{}
+	if err := applyResourceInput(&resource, input); err != nil {
+		return model.OidcResource{}, err
+	}
+
+	if err := s.db.Create(&resource).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.OidcResource{}, &common.AlreadyInUseError{Property: "identifier"}
+		}
+		return model.OidcResource{}, err
+	}
+
+	return resource, nil
+}
+
+func (s *OidcService) UpdateResource(id string, input dto.OidcResourceCreateDto) (model.OidcResource, error) {
+	resource, err := s.GetResource(id)
+	if err != nil {
+		return model.OidcResource{}, err
+	}
+
+	if err := applyResourceInput(&resource, input); err != nil {
+		return model.OidcResource{}, err
+	}
+
+	if err := s.db.Omit("AllowedClients").Save(&resource).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.OidcResource{}, &common.AlreadyInUseError{Property: "identifier"}
+		}
+		return model.OidcResource{}, err
+	}
+
+	return resource, nil
+}
+
+func (s *OidcService) DeleteResource(id string) error {
+	var resource model.OidcResource
+	if err := s.db.First(&resource, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	// The allowed clients are deleted explicitly because SQLite doesn't enforce the foreign keys of the join table
+	return s.db.Select("AllowedClients").Delete(&resource).Error
+}
+
+// UpdateResourceAllowedClients replaces the clients that are allowed to request access tokens for the resource
+func (s *OidcService) UpdateResourceAllowedClients(id string, input dto.OidcResourceUpdateAllowedClientsDto) (model.OidcResource, error) {
+	resource, err := s.GetResource(id)
+	if err != nil {
+		return model.OidcResource{}, err
+	}
+
+	var clients []model.OidcClient
+	if len(input.ClientIDs) > 0 {
+		if err := s.db.Where("id IN (?)", input.ClientIDs).Find(&clients).Error; err != nil {
+			return model.OidcResource{}, err
+		}
+	}
+
+	if err := s.db.Model(&resource).Association("AllowedClients").Replace(clients); err != nil {
+		return model.OidcResource{}, err
+	}
+
+	return resource, nil
+}
+
+// applyResourceInput sets the fields of the resource. The identifier is the audience of the access tokens,
+// so it has to be an absolute URI without a fragment as required by RFC 8707.
+func applyResourceInput(resource *model.OidcResource, input dto.OidcResourceCreateDto) error {
+	if !isValidResourceIdentifier(input.Identifier) {
+		return &common.OidcInvalidResourceIdentifierError{}
+	}
+
+	scopes := model.StringList{}
+	for _, scope := range input.Scopes {
+		if !isValidScopeToken(scope) {
+			return &common.InvalidScopeNameError{}
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	resource.Name = input.Name
+	resource.Identifier = input.Identifier
+	resource.Scopes = scopes
+	return nil
+}
+
+// isValidResourceIdentifier reports whether the identifier is an absolute URI without a fragment.
+// Pocket ID itself can't be a resource because its session tokens are accepted for the app URL as audience.
+func isValidResourceIdentifier(identifier string) bool {
+	appURL := strings.TrimSuffix(common.EnvConfig.AppURL, "/")
+	if strings.TrimSuffix(identifier, "/") == appURL || strings.HasPrefix(identifier, appURL+"/") {
+		return false
+	}
+
+	parsedURL, err := url.Parse(identifier)
+	return err == nil && parsedURL.IsAbs() && parsedURL.Fragment == "" && !strings.Contains(identifier, "#")
+}
+
+// allowedResources returns the API resources the client is allowed to request access tokens for
+// and the scopes of the resources it isn't allowed to request
+func (s *OidcService) allowedResources(clientID string) ([]model.OidcResource, []string, error) {
+	var resources []model.OidcResource
+	if err := s.db.Preload("AllowedClients").Find(&resources).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var allowed []model.OidcResource
+	var restrictedScopes []string
+	for _, resource := range resources {
+		isAllowed := slices.ContainsFunc(resource.AllowedClients, func(client model.OidcClient) bool {
+			return client.ID == clientID
+		})
+		if isAllowed {
+			allowed = append(allowed, resource)
+		} else {
+			restrictedScopes = append(restrictedScopes, resource.Scopes...)
+		}
+	}
+
+	return allowed, restrictedScopes, nil
+}
+
+// resolveResources returns the identifiers of the API resources an access token is requested for as defined by RFC 8707.
+// Explicitly requested resources must be allowed for the client. Requesting a scope of a resource implies the resource.
+func (s *OidcService) resolveResources(clientID string, requested []string, scope string) ([]string, error) {
+	allowed, restrictedScopes, err := s.allowedResources(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []string{}
+	for _, identifier := range requested {
+		isAllowed := slices.ContainsFunc(allowed, func(resource model.OidcResource) bool {
+			return resource.Identifier == identifier
+		})
+		if !isValidResourceIdentifier(identifier) || !isAllowed {
+			return nil, &common.OidcInvalidTargetError{}
+		}
+		if !slices.Contains(resources, identifier) {
+			resources = append(resources, identifier)
+		}
+	}
+
+	for _, requestedScope := range strings.Fields(scope) {
+		impliesResource := false
+		for _, resource := range allowed {
+			if !slices.Contains(resource.Scopes, requestedScope) {
+				continue
+			}
+			impliesResource = true
+			if !slices.Contains(resources, resource.Identifier) {
+				resources = append(resources, resource.Identifier)
+			}
+		}
+
+		// The scopes of resources the client isn't allowed to request can't be granted
+		if !impliesResource && slices.Contains(restrictedScopes, requestedScope) {
+			return nil, &common.OidcInvalidScopeError{}
+		}
+	}
+
+	return resources, nil
+}
+
+// narrowResources returns the requested resources, which must have been granted before. Without a request all granted resources are returned.
+func narrowResources(granted, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
+
+	for _, identifier := range requested {
+		if !slices.Contains(granted, identifier) {
+			return nil, &common.OidcInvalidTargetError{}
+		}
+	}
+	return requested, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+)
+
+func TestApplyResourceInputWithInvalidIdentifier(t *testing.T) {
+	appURL := common.EnvConfig.AppURL
+	common.EnvConfig.AppURL = "https://id.example"
+	t.Cleanup(func() { common.EnvConfig.AppURL = appURL })
+
+	for _, identifier := range []string{
+		"api.example",
+		"https://api.example#fragment",
+		"https://id.example",
+		"https://id.example/",
+		"https://id.example/api",
+	} {
+		var resource model.OidcResource
+		err := applyResourceInput(&resource, dto.OidcResourceCreateDto{Name: "API", Identifier: identifier})
+		var identifierErr *common.OidcInvalidResourceIdentifierError
+		if !errors.As(err, &identifierErr) {
+			t.Errorf("expected an invalid identifier error for '%s', got: %v", identifier, err)
+		}
+	}
+
+	var resource model.OidcResource
+	if err := applyResourceInput(&resource, dto.OidcResourceCreateDto{Name: "API", Identifier: "https://id.example.org/api"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	datatype "github.com/pocket-id/pocket-id/backend/internal/model/types"
+	"github.com/pocket-id/pocket-id/backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokeToken revokes an access or refresh token that was issued to the client. Invalid tokens are ignored as required by RFC 7009.
+func (s *OidcService) RevokeToken(input dto.OidcRevokeTokenDto) error {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return err
+	}
+
+	if input.TokenTypeHint == "refresh_token" {
+		revoked, err := s.revokeRefreshToken(input.Token, client.ID)
+		if err != nil || revoked {
+			return err
+		}
+		return s.revokeAccessToken(input.Token, client.ID)
+	}
+
+	if err := s.revokeAccessToken(input.Token, client.ID); err != nil {
+		return err
+	}
+	_, err = s.revokeRefreshToken(input.Token, client.ID)
+	return err
+}
+
+func (s *OidcService) revokeAccessToken(token, clientID string) error {
+	claims, err := s.jwtService.VerifyOauthAccessToken(token)
+	if err != nil || claims.ID == "" || claims.GetClientID() != clientID {
+		return nil
+	}
+
+	revokedToken := model.OidcRevokedToken{
+		Base:      model.Base{ID: claims.ID},
+		ExpiresAt: datatype.DateTime(claims.ExpiresAt.Time),
+	}
+
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&revokedToken).Error
+}
+
+// revokeRefreshToken deletes the refresh token together with all refresh tokens that were rotated from the same grant
+func (s *OidcService) revokeRefreshToken(token, clientID string) (bool, error) {
+	var refreshToken model.OidcRefreshToken
+	if err := s.db.First(&refreshToken, "token = ? AND client_id = ?", utils.CreateSha256Hash(token), clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := s.db.Delete(&model.OidcRefreshToken{}, "family_id = ?", refreshToken.FamilyID).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ListScopes returns the scopes defined by the admins
+func (s *OidcService) ListScopes() ([]model.OidcScope, error) {
+	var scopes []model.OidcScope
+	if err := s.db.Order("name").Find(&scopes).Error; err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+func (s *OidcService) CreateScope(input dto.OidcScopeCreateDto) (model.OidcScope, error) {
+	scope := model.OidcScope{}
+	if err := applyScopeInput(&scope, input); err != nil {
+		return model.OidcScope{}, err
+	}
+
+	if err := s.db.Create(&scope).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.OidcScope{}, &common.AlreadyInUseError{Property: "name"}
+		}
+		return model.OidcScope{}, err
+	}
+
+	return scope, nil
+}
+
+func (s *OidcService) UpdateScope(id string, input dto.OidcScopeCreateDto) (model.OidcScope, error) {
+	var scope model.OidcScope
+	if err := s.db.First(&scope, "id = ?", id).Error; err != nil {
+		return model.OidcScope{}, err
+	}
+
+	if err := applyScopeInput(&scope, input); err != nil {
+		return model.OidcScope{}, err
+	}
+
+	if err := s.db.Save(&scope).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.OidcScope{}, &common.AlreadyInUseError{Property: "name"}
+		}
+		return model.OidcScope{}, err
+	}
+
+	return scope, nil
+}
+
+func (s *OidcService) DeleteScope(id string) error {
+	var scope model.OidcScope
+	if err := s.db.First(&scope, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	return s.db.Delete(&scope).Error
+}
+
+// applyScopeInput sets the fields of the scope. The built-in scopes can't be redefined.
+func applyScopeInput(scope *model.OidcScope, input dto.OidcScopeCreateDto) error {
+	if !isValidScopeToken(input.Name) {
+		return &common.InvalidScopeNameError{}
+	}
+	if slices.Contains(BuiltInScopes, input.Name) {
+		return &common.ReservedScopeError{Name: input.Name}
+	}
+
+	claims := model.StringList{}
+	for _, claim := range input.Claims {
+		if claim == "" || strings.ContainsAny(claim, " \t\n") {
+			return &common.OidcInvalidRequestError{Message: "claim names can't contain whitespace"}
+		}
+		if !slices.Contains(claims, claim) {
+			claims = append(claims, claim)
+		}
+	}
+
+	scope.Name = input.Name
+	scope.Description = input.Description
+	scope.Claims = claims
+	return nil
+}
+
+// isValidScopeToken checks that the value only contains the characters RFC 6749 section 3.3 allows in a scope token
+func isValidScopeToken(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, char := range value {
+		if char < 0x21 || char > 0x7e || char == '"' || char == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// grantedClaims returns the names of the claims that are released by the built-in and custom scopes of the scope parameter
+func (s *OidcService) grantedClaims(scope string) (map[string]bool, error) {
+	scopeNames := strings.Fields(scope)
+
+	var customScopes []model.OidcScope
+	if len(scopeNames) > 0 {
+		if err := s.db.Where("name IN ?", scopeNames).Find(&customScopes).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	grantedClaims := map[string]bool{}
+	for _, scopeName := range scopeNames {
+		for _, claim := range builtInScopeClaims[scopeName] {
+			grantedClaims[claim] = true
+		}
+	}
+	for _, customScope := range customScopes {
+		for _, claim := range customScope.Claims {
+			grantedClaims[claim] = true
+		}
+	}
+
+	return grantedClaims, nil
+}
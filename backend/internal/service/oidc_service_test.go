@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	sqliteMigrate "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/pocket-id/pocket-id/backend/resources"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestOidcService returns an OIDC service with an empty in-memory database
+func newTestOidcService(t *testing.T) *OidcService {
+	t.Helper()
+
+	return &OidcService{
+		db:                    newTestDatabase(t),
+		dpopNonceKey:          []byte("dpop-nonce-key"),
+		pairwiseSubjectSecret: []byte("pairwise-subject-secret"),
+		clientKeysCache:       make(map[string]cachedClientKeys),
+	}
+}
+
+// newTestDatabase returns an in-memory sqlite database with all migrations applied
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to an in-memory database opens a new database
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+
+	driver, err := sqliteMigrate.WithInstance(sqlDb, &sqliteMigrate.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := iofs.New(resources.FS, "migrations/sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "pocket-id", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestTokenHash(t *testing.T) {
+	// The hash function depends on the algorithm the ID token is signed with
+	var testData = map[string]string{
+		"RS256": "Qp7SWlownpYr2lQgC_jxGA",
+		"PS256": "Qp7SWlownpYr2lQgC_jxGA",
+		"PS384": "GQB78NXFV7Hx0acQEjNMB2Du0LSIFvHx",
+		"RS512": "ng6Kc5H8EyHfXZ675Hy3chY1bh6ttSA19GgKcjteMKU",
+	}
+	for alg, expected := range testData {
+		if got := tokenHash("jHkWEdUXMU1BwAsC4vtUsZwnNnPPxL4kyOj-IwIs", alg); got != expected {
+			t.Errorf("%s: expected '%s', got: '%s'", alg, expected, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/pocket-id/pocket-id/backend/internal/common"
+	"github.com/pocket-id/pocket-id/backend/internal/dto"
+	"github.com/pocket-id/pocket-id/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// createTokensFromTokenExchange exchanges an access token of a user for an access token of another client as defined by RFC 8693.
+// The requesting client acts on behalf of the user, so it is recorded in the act claim of the new token.
+func (s *OidcService) createTokensFromTokenExchange(input dto.OidcCreateTokensDto, ipAddress, userAgent string) (dto.OidcTokenResponseDto, error) {
+	client, err := s.authenticateClient(input.OidcClientCredentialsDto)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// Only confidential clients with allowed audiences can exchange tokens
+	if client.IsPublic || len(client.TokenExchangeAudiences) == 0 {
+		return dto.OidcTokenResponseDto{}, &common.OidcUnauthorizedClientError{}
+	}
+
+	if input.SubjectToken == "" || input.SubjectTokenType != tokenTypeAccessToken {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRequestError{Message: "subject_token must be an access token"}
+	}
+	if input.ActorToken != "" {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRequestError{Message: "actor_token is not supported"}
+	}
+	if input.RequestedTokenType != "" && input.RequestedTokenType != tokenTypeAccessToken {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidRequestError{Message: "requested_token_type is not supported"}
+	}
+
+	// The client can only exchange user tokens that were issued to itself
+	subjectClaims, err := s.VerifyOauthAccessToken(input.SubjectToken)
+	if err != nil || subjectClaims.GetClientID() != client.ID || subjectClaims.Subject == client.ID {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidSubjectTokenError{}
+	}
+
+	if input.Audience == "" || !slices.Contains(client.TokenExchangeAudiences, input.Audience) {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidTargetError{}
+	}
+
+	var targetClient model.OidcClient
+	if err := s.db.Preload("AllowedUserGroups").First(&targetClient, "id = ?", input.Audience).Error; err != nil {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidTargetError{}
+	}
+
+	userID, err := s.UserIDFromSubject(subjectClaims.Subject)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	var user model.User
+	if err := s.db.Preload("UserGroups").First(&user, "id = ?", userID).Error; err != nil {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidSubjectTokenError{}
+	}
+	if !s.IsUserGroupAllowedToAuthorize(user, targetClient) {
+		return dto.OidcTokenResponseDto{}, &common.OidcAccessDeniedError{}
+	}
+
+	// The user has to have authorized the target client, otherwise the exchange would grant it access the user never agreed to
+	var targetAuthorization model.UserAuthorizedOidcClient
+	if err := s.db.First(&targetAuthorization, "user_id = ? AND client_id = ?", user.ID, targetClient.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.OidcTokenResponseDto{}, &common.OidcInvalidTargetError{}
+		}
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// The token can only be downscoped and is limited to the scopes the user granted the target client
+	scope := intersectScopes(subjectClaims.Scope, targetAuthorization.Scope)
+	if input.Scope != "" {
+		if !containsScopes(scope, input.Scope) {
+			return dto.OidcTokenResponseDto{}, &common.OidcInvalidScopeError{}
+		}
+		scope = input.Scope
+	}
+	if scope == "" {
+		return dto.OidcTokenResponseDto{}, &common.OidcInvalidScopeError{}
+	}
+
+	confirmation, err := s.tokenConfirmation(client, input)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	// A sender-constrained subject token can only be exchanged with the key or certificate it is bound to,
+	// which also binds the new token to it. Otherwise a stolen token could be exchanged for a bearer token.
+	if subjectClaims.Cnf != nil {
+		if subjectClaims.Cnf.Jkt != "" && (confirmation == nil || confirmation.Jkt != subjectClaims.Cnf.Jkt) {
+			return dto.OidcTokenResponseDto{}, &common.OidcInvalidSubjectTokenError{}
+		}
+		if subjectClaims.Cnf.X5tS256 != "" && (confirmation == nil || confirmation.X5tS256 != subjectClaims.Cnf.X5tS256) {
+			return dto.OidcTokenResponseDto{}, &common.OidcInvalidSubjectTokenError{}
+		}
+	}
+
+	// Previous actors are kept to record the whole delegation chain
+	actor := &dto.OidcTokenActorDto{Sub: client.ID, Act: subjectClaims.Act}
+	subject, err := s.subjectForClient(targetClient, user.ID)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+	// The user authenticated when the subject token was issued, so the exchanged token keeps the authentication time
+	tokenUser := &dto.OidcAccessTokenUserDto{}
+	if subjectClaims.AuthTime != nil {
+		tokenUser.AuthTime = &subjectClaims.AuthTime.Time
+	}
+	if hasScope(scope, "groups") {
+		tokenUser.Groups = make([]string, len(user.UserGroups))
+		for i, group := range user.UserGroups {
+			tokenUser.Groups[i] = group.Name
+		}
+	}
+	if hasScope(scope, "roles") {
+		tokenUser.Roles = subjectClaims.Roles
+	}
+	accessTokenLifetime := s.tokenLifetimes(targetClient).accessToken
+	accessToken, err := s.jwtService.GenerateOauthAccessToken(subject, targetClient.ID, nil, scope, tokenUser, confirmation, actor, accessTokenLifetime)
+	if err != nil {
+		return dto.OidcTokenResponseDto{}, err
+	}
+
+	s.auditLogService.Create(model.AuditLogEventTokenExchange, ipAddress, userAgent, user.ID, model.AuditLogData{"clientName": client.Name, "clientId": client.ID, "audience": targetClient.ID, "scope": scope})
+
+	return dto.OidcTokenResponseDto{
+		AccessToken:     accessToken,
+		TokenType:       tokenType(confirmation),
+		ExpiresIn:       int(accessTokenLifetime.Seconds()),
+		Scope:           scope,
+		IssuedTokenType: tokenTypeAccessToken,
+	}, nil
+}
@@ -59,7 +59,14 @@ func (s *UserService) DeleteUser(userID string) error {
 		return &common.LdapUserUpdateError{}
 	}
 
-	return s.db.Delete(&user).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Revoke the refresh tokens that were issued to the user
+		if err := tx.Delete(&model.OidcRefreshToken{}, "user_id = ?", user.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&user).Error
+	})
 }
 
 func (s *UserService) CreateUser(input dto.UserCreateDto) (model.User, error) {
@@ -178,7 +185,7 @@ func (s *UserService) ExchangeOneTimeAccessToken(token string, ipAddress, userAg
 		}
 		return model.User{}, "", err
 	}
-	accessToken, err := s.jwtService.GenerateAccessToken(oneTimeAccessToken.User)
+	accessToken, err := s.jwtService.GenerateAccessToken(oneTimeAccessToken.User, []string{"otp"})
 	if err != nil {
 		return model.User{}, "", err
 	}
@@ -219,7 +226,7 @@ func (s *UserService) SetupInitialAdmin() (model.User, string, error) {
 		return model.User{}, "", &common.SetupAlreadyCompletedError{}
 	}
 
-	token, err := s.jwtService.GenerateAccessToken(user)
+	token, err := s.jwtService.GenerateAccessToken(user, nil)
 	if err != nil {
 		return model.User{}, "", err
 	}
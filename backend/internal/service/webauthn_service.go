@@ -158,7 +158,7 @@ func (s *WebAuthnService) VerifyLogin(sessionID string, credentialAssertionData
 		return model.User{}, "", err
 	}
 
-	token, err := s.jwtService.GenerateAccessToken(*user)
+	token, err := s.jwtService.GenerateAccessToken(*user, []string{"hwk", "user"})
 	if err != nil {
 		return model.User{}, "", err
 	}
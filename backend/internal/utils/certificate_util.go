@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ParseForwardedCertificate parses a client certificate that was forwarded by a reverse proxy in a header.
+// The certificate can either be URL encoded PEM, like the one Nginx sends, or base64 encoded DER.
+func ParseForwardedCertificate(value string) (*x509.Certificate, error) {
+	unescapedValue, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode([]byte(unescapedValue)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(unescapedValue))
+	if err != nil {
+		return nil, errors.New("certificate is neither PEM nor base64 encoded DER")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// CertificateThumbprint returns the base64url encoded SHA-256 thumbprint of the certificate as defined by RFC 8705
+func CertificateThumbprint(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// LoadCertPool loads the PEM encoded certificates of the file into a certificate pool
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in " + path)
+	}
+	return pool, nil
+}
+
+// IsIPInList returns true if the IP address matches one of the IP addresses or CIDR ranges of the list
+func IsIPInList(ip string, list []string) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+
+	for _, entry := range list {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(parsedIP) {
+				return true
+			}
+		} else if listedIP := net.ParseIP(entry); listedIP != nil && listedIP.Equal(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
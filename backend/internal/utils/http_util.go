@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrRestrictedAddress is returned when a restricted HTTP client tries to connect to a non-public address
+var ErrRestrictedAddress = errors.New("connections to loopback, private and link-local addresses are not allowed")
+
+// NewRestrictedHTTPClient returns a HTTP client for URLs that are supplied by untrusted parties. It refuses to connect to
+// loopback, private and link-local addresses, so that it can't be used to reach services in the internal network.
+// The address is checked after the host name was resolved, which also covers redirects and DNS rebinding.
+func NewRestrictedHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicAddress(addrPort.Addr()) {
+				return ErrRestrictedAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would connect to the target on our behalf and bypass the address check
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
+
+// IsPublicAddress returns true if the address is a globally routable unicast address
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	// Shared address space for carrier-grade NAT (RFC 6598)
+	return !netip.MustParsePrefix("100.64.0.0/10").Contains(addr)
+}
+
+// IsLoopbackHost returns true if the host is localhost or a loopback IP address
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+// FetchURL downloads the resource at the URL and returns at most maxSize bytes of it
+func FetchURL(httpClient *http.Client, uri string, maxSize int64) ([]byte, error) {
+	res, err := httpClient.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, maxSize))
+}
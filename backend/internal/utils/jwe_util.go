@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// JWEKeyManagementAlgorithms are the algorithms the content encryption key of a JWE can be encrypted or agreed upon with
+var JWEKeyManagementAlgorithms = []string{"RSA-OAEP", "RSA-OAEP-256", "ECDH-ES"}
+
+// JWEContentEncryptionAlgorithms are the algorithms the content of a JWE can be encrypted with
+var JWEContentEncryptionAlgorithms = []string{"A128CBC-HS256", "A256CBC-HS512", "A128GCM", "A256GCM"}
+
+// MatchesEncryptionAlgorithm returns true if the key can be used to encrypt content encryption keys with the given JWE algorithm
+func (k PublicJWK) MatchesEncryptionAlgorithm(alg string) bool {
+	if k.Use != "" && k.Use != "enc" {
+		return false
+	}
+	if k.Alg != "" {
+		return k.Alg == alg
+	}
+
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		return k.Kty == "RSA"
+	case "ECDH-ES":
+		return k.Kty == "EC"
+	default:
+		return false
+	}
+}
+
+// EncryptJWE encrypts the plaintext to the key and returns the JWE in compact serialization as defined by RFC 7516.
+// The content type should be "JWT" if the plaintext is a signed JWT, so the result is a nested JWT.
+func EncryptJWE(plaintext []byte, key PublicJWK, alg, enc, contentType string) (string, error) {
+	cekLength, err := contentEncryptionKeyLength(enc)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]interface{}{"alg": alg, "enc": enc}
+	if key.Kid != "" {
+		header["kid"] = key.Kid
+	}
+	if contentType != "" {
+		header["cty"] = contentType
+	}
+
+	var cek, encryptedKey []byte
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		rsaKey, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("%s requires an RSA key", alg)
+		}
+
+		cek = make([]byte, cekLength)
+		if _, err := rand.Read(cek); err != nil {
+			return "", err
+		}
+
+		var oaepHash hash.Hash = sha1.New()
+		if alg == "RSA-OAEP-256" {
+			oaepHash = sha256.New()
+		}
+		encryptedKey, err = rsa.EncryptOAEP(oaepHash, rand.Reader, rsaKey, cek, nil)
+		if err != nil {
+			return "", err
+		}
+	case "ECDH-ES":
+		ecKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return "", errors.New("ECDH-ES requires an EC key")
+		}
+
+		var epk map[string]string
+		cek, epk, err = deriveECDHESKey(ecKey, key.Crv, enc, cekLength)
+		if err != nil {
+			return "", err
+		}
+		header["epk"] = epk
+	default:
+		return "", fmt.Errorf("unsupported key management algorithm %s", alg)
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	// The encoded header is the additional authenticated data of the content encryption
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerJSON)
+
+	iv, ciphertext, tag, err := encryptContent(cek, enc, plaintext, []byte(encodedHeader))
+	if err != nil {
+		return "", err
+	}
+
+	return encodedHeader + "." +
+		base64.RawURLEncoding.EncodeToString(encryptedKey) + "." +
+		base64.RawURLEncoding.EncodeToString(iv) + "." +
+		base64.RawURLEncoding.EncodeToString(ciphertext) + "." +
+		base64.RawURLEncoding.EncodeToString(tag), nil
+}
+
+// contentEncryptionKeyLength returns the length of the content encryption key of the algorithm in bytes
+func contentEncryptionKeyLength(enc string) (int, error) {
+	switch enc {
+	case "A128CBC-HS256":
+		return 32, nil
+	case "A256CBC-HS512":
+		return 64, nil
+	case "A128GCM":
+		return 16, nil
+	case "A256GCM":
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("unsupported content encryption algorithm %s", enc)
+	}
+}
+
+// deriveECDHESKey agrees upon the content encryption key with an ephemeral key as defined by RFC 7518 section 4.6.
+// It returns the key and the public ephemeral key as a JWK.
+func deriveECDHESKey(publicKey *ecdsa.PublicKey, crv, enc string, keyLength int) ([]byte, map[string]string, error) {
+	recipientKey, err := publicKey.ECDH()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ephemeralKey, err := recipientKey.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedSecret, err := ephemeralKey.ECDH(recipientKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The uncompressed point is 0x04 followed by the X and Y coordinates of equal length
+	point := ephemeralKey.PublicKey().Bytes()
+	coordinateLength := (len(point) - 1) / 2
+	epk := map[string]string{
+		"kty": "EC",
+		"crv": crv,
+		"x":   base64.RawURLEncoding.EncodeToString(point[1 : 1+coordinateLength]),
+		"y":   base64.RawURLEncoding.EncodeToString(point[1+coordinateLength:]),
+	}
+
+	// In direct key agreement mode the algorithm ID is the content encryption algorithm. No party info is sent.
+	return concatKDF(sharedSecret, enc, nil, nil, keyLength), epk, nil
+}
+
+// concatKDF derives a key from the shared secret with the Concat KDF of NIST SP 800-56A as used by RFC 7518 section 4.6.2.
+// The party info are the decoded apu and apv header parameters.
+func concatKDF(sharedSecret []byte, algorithmID string, partyUInfo, partyVInfo []byte, keyLength int) []byte {
+	var otherInfo []byte
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(algorithmID)))
+	otherInfo = append(otherInfo, algorithmID...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(partyUInfo)))
+	otherInfo = append(otherInfo, partyUInfo...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(partyVInfo)))
+	otherInfo = append(otherInfo, partyVInfo...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keyLength*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < keyLength; counter++ {
+		h := sha256.New()
+		_ = binary.Write(h, binary.BigEndian, counter)
+		h.Write(sharedSecret)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+
+	return key[:keyLength]
+}
+
+// encryptContent encrypts the plaintext with the content encryption key and a random IV and returns the IV,
+// the ciphertext and the authentication tag
+func encryptContent(cek []byte, enc string, plaintext, additionalData []byte) ([]byte, []byte, []byte, error) {
+	// GCM uses a 96 bit IV and CBC the block size
+	iv := make([]byte, 12)
+	if enc == "A128CBC-HS256" || enc == "A256CBC-HS512" {
+		iv = make([]byte, aes.BlockSize)
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	ciphertext, tag, err := encryptContentWithIV(cek, iv, enc, plaintext, additionalData)
+	return iv, ciphertext, tag, err
+}
+
+// encryptContentWithIV encrypts the plaintext with the content encryption key and the IV as defined by RFC 7518 section 5
+// and returns the ciphertext and the authentication tag
+func encryptContentWithIV(cek, iv []byte, enc string, plaintext, additionalData []byte) ([]byte, []byte, error) {
+	switch enc {
+	case "A128GCM", "A256GCM":
+		block, err := aes.NewCipher(cek)
+		if err != nil {
+			return nil, nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sealed := gcm.Seal(nil, iv, plaintext, additionalData)
+		tagStart := len(sealed) - gcm.Overhead()
+		return sealed[:tagStart], sealed[tagStart:], nil
+	case "A128CBC-HS256", "A256CBC-HS512":
+		// The first half of the key is the MAC key and the second half the encryption key
+		macKey, encKey := cek[:len(cek)/2], cek[len(cek)/2:]
+		hashFunc := sha256.New
+		if enc == "A256CBC-HS512" {
+			hashFunc = sha512.New
+		}
+
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// PKCS #7 padding
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		padded := append([]byte{}, plaintext...)
+		for i := 0; i < padding; i++ {
+			padded = append(padded, byte(padding))
+		}
+
+		ciphertext := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+		mac := hmac.New(hashFunc, macKey)
+		mac.Write(additionalData)
+		mac.Write(iv)
+		mac.Write(ciphertext)
+		_ = binary.Write(mac, binary.BigEndian, uint64(len(additionalData)*8))
+		tag := mac.Sum(nil)[:len(macKey)]
+
+		return ciphertext, tag, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported content encryption algorithm %s", enc)
+	}
+}
@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// kerckhoffsPlaintext, kerckhoffsIV and kerckhoffsAAD are the inputs of the test cases of RFC 7518 appendix B
+const (
+	kerckhoffsPlaintext = "A cipher system must not be required to be secret, and it must be able to fall into the hands of the enemy without inconvenience"
+	kerckhoffsIV        = "1af38c2dc2b96ffdd86694092341bc04"
+	kerckhoffsAAD       = "The second principle of Auguste Kerckhoffs"
+)
+
+type encryptContentTestData struct {
+	name       string
+	enc        string
+	cek        []byte
+	iv         []byte
+	plaintext  string
+	aad        string
+	ciphertext string
+	tag        string
+}
+
+func TestEncryptContentWithIV(t *testing.T) {
+	var testData = []encryptContentTestData{
+		{
+			// RFC 7516 appendix A.1
+			name: "A256GCM",
+			enc:  "A256GCM",
+			cek: []byte{177, 161, 244, 128, 84, 143, 225, 115, 63, 180, 3, 255, 107, 154, 212, 246,
+				138, 7, 110, 91, 112, 46, 34, 105, 47, 130, 203, 46, 122, 234, 64, 252},
+			iv:         []byte{227, 197, 117, 252, 2, 219, 233, 68, 180, 225, 77, 219},
+			plaintext:  "The true sign of intelligence is not knowledge but imagination.",
+			aad:        "eyJhbGciOiJSU0EtT0FFUCIsImVuYyI6IkEyNTZHQ00ifQ",
+			ciphertext: "5eym8TW_c8SuK0ltJ3rpYIzOeDQz7TALvtu6UG9oMo4vpzs9tX_EFShS8iB7j6jiSdiwkIr3ajwQzaBtQD_A",
+			tag:        "XFBoMYUZodetZdvTiFvSkQ",
+		},
+		{
+			// RFC 7516 appendix A.2
+			name: "A128CBC-HS256",
+			enc:  "A128CBC-HS256",
+			cek: []byte{4, 211, 31, 197, 84, 157, 252, 254, 11, 100, 157, 250, 63, 170, 106, 206,
+				107, 124, 212, 45, 111, 107, 9, 219, 200, 177, 0, 240, 143, 156, 44, 207},
+			iv:         []byte{3, 22, 60, 12, 43, 67, 104, 105, 108, 108, 105, 99, 111, 116, 104, 101},
+			plaintext:  "Live long and prosper.",
+			aad:        "eyJhbGciOiJSU0ExXzUiLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0",
+			ciphertext: "KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY",
+			tag:        "9hH0vgRfYgPnAHOd8stkvw",
+		},
+	}
+
+	for _, data := range testData {
+		ciphertext, tag, err := encryptContentWithIV(data.cek, data.iv, data.enc, []byte(data.plaintext), []byte(data.aad))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", data.name, err)
+			continue
+		}
+		if got := base64.RawURLEncoding.EncodeToString(ciphertext); got != data.ciphertext {
+			t.Errorf("%s: expected ciphertext '%s', got: '%s'", data.name, data.ciphertext, got)
+		}
+		if got := base64.RawURLEncoding.EncodeToString(tag); got != data.tag {
+			t.Errorf("%s: expected tag '%s', got: '%s'", data.name, data.tag, got)
+		}
+	}
+}
+
+func TestEncryptContentWithIVAuthenticationTag(t *testing.T) {
+	// RFC 7518 appendix B.1 and B.3, the keys are the byte sequences 00 01 02 ...
+	var testData = map[string]string{
+		"A128CBC-HS256": "652c3fa36b0a7c5b3219fab3a30bc1c4",
+		"A256CBC-HS512": "4dd3b4c088a7f45c216839645b2012bf2e6269a8c56a816dbc1b267761955bc5",
+	}
+
+	iv, _ := hex.DecodeString(kerckhoffsIV)
+	for enc, expected := range testData {
+		keyLength, _ := contentEncryptionKeyLength(enc)
+		cek := make([]byte, keyLength)
+		for i := range cek {
+			cek[i] = byte(i)
+		}
+
+		_, tag, err := encryptContentWithIV(cek, iv, enc, []byte(kerckhoffsPlaintext), []byte(kerckhoffsAAD))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", enc, err)
+			continue
+		}
+		if got := hex.EncodeToString(tag); got != expected {
+			t.Errorf("%s: expected tag '%s', got: '%s'", enc, expected, got)
+		}
+	}
+}
+
+func TestConcatKDF(t *testing.T) {
+	// RFC 7518 appendix C
+	ephemeralKey := ecdhPrivateKey(t, "0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo")
+	recipientKey := ecdhPrivateKey(t, "VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw")
+
+	expectedEphemeralX := "gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0"
+	if got := base64.RawURLEncoding.EncodeToString(ephemeralKey.PublicKey().Bytes()[1:33]); got != expectedEphemeralX {
+		t.Fatalf("expected ephemeral x '%s', got: '%s'", expectedEphemeralX, got)
+	}
+
+	sharedSecret, err := ephemeralKey.ECDH(recipientKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "VqqN6vgjbSBcIijNcacQGg"
+	key := concatKDF(sharedSecret, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	if got := base64.RawURLEncoding.EncodeToString(key); got != expected {
+		t.Errorf("expected key '%s', got: '%s'", expected, got)
+	}
+}
+
+func TestEncryptJWEWithRSA(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := PublicJWK{
+		Kty: "RSA",
+		Kid: "rsa-key",
+		N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+	}
+
+	var testData = map[string]hash.Hash{
+		"RSA-OAEP":     sha1.New(),
+		"RSA-OAEP-256": sha256.New(),
+	}
+	for alg, oaepHash := range testData {
+		for _, enc := range JWEContentEncryptionAlgorithms {
+			jwe, err := EncryptJWE([]byte("secret"), key, alg, enc, "JWT")
+			if err != nil {
+				t.Errorf("%s %s: unexpected error: %v", alg, enc, err)
+				continue
+			}
+
+			header, parts := parseJWE(t, jwe)
+			if header["alg"] != alg || header["enc"] != enc || header["kid"] != "rsa-key" || header["cty"] != "JWT" {
+				t.Errorf("%s %s: unexpected header %v", alg, enc, header)
+			}
+
+			oaepHash.Reset()
+			cek, err := rsa.DecryptOAEP(oaepHash, nil, privateKey, parts[1], nil)
+			if err != nil {
+				t.Errorf("%s %s: can't decrypt the content encryption key: %v", alg, enc, err)
+				continue
+			}
+
+			if got := decryptContent(t, cek, enc, jwe, parts); got != "secret" {
+				t.Errorf("%s %s: expected plaintext 'secret', got: '%s'", alg, enc, got)
+			}
+		}
+	}
+}
+
+func TestEncryptJWEWithECDHES(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipientKey, err := privateKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := recipientKey.PublicKey().Bytes()
+	key := PublicJWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}
+
+	for _, enc := range JWEContentEncryptionAlgorithms {
+		jwe, err := EncryptJWE([]byte("secret"), key, "ECDH-ES", enc, "")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", enc, err)
+			continue
+		}
+
+		header, parts := parseJWE(t, jwe)
+		if len(parts[1]) != 0 {
+			t.Errorf("%s: direct key agreement must not have an encrypted key", enc)
+		}
+
+		// The recipient derives the same key from the ephemeral public key in the header
+		epk := header["epk"].(map[string]interface{})
+		x, _ := base64.RawURLEncoding.DecodeString(epk["x"].(string))
+		y, _ := base64.RawURLEncoding.DecodeString(epk["y"].(string))
+		ephemeralKey, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...))
+		if err != nil {
+			t.Errorf("%s: invalid ephemeral key: %v", enc, err)
+			continue
+		}
+		sharedSecret, err := recipientKey.ECDH(ephemeralKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyLength, _ := contentEncryptionKeyLength(enc)
+		cek := concatKDF(sharedSecret, enc, nil, nil, keyLength)
+
+		if got := decryptContent(t, cek, enc, jwe, parts); got != "secret" {
+			t.Errorf("%s: expected plaintext 'secret', got: '%s'", enc, got)
+		}
+	}
+}
+
+func TestEncryptJWEWithUnsupportedAlgorithms(t *testing.T) {
+	point := ecdhPrivateKey(t, "VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw").PublicKey().Bytes()
+	key := PublicJWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}
+	if _, err := EncryptJWE([]byte("secret"), key, "ECDH-ES", "A128GCM", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := EncryptJWE([]byte("secret"), key, "ECDH-ES", "A192GCM", ""); err == nil {
+		t.Errorf("expected an error for an unsupported content encryption algorithm")
+	}
+	if _, err := EncryptJWE([]byte("secret"), key, "A128KW", "A128GCM", ""); err == nil {
+		t.Errorf("expected an error for an unsupported key management algorithm")
+	}
+}
+
+// ecdhPrivateKey returns the P-256 key with the base64url encoded private key d
+func ecdhPrivateKey(t *testing.T, d string) *ecdh.PrivateKey {
+	t.Helper()
+
+	keyBytes, err := base64.RawURLEncoding.DecodeString(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(keyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// parseJWE splits the JWE in compact serialization and returns the decoded header and parts
+func parseJWE(t *testing.T, jwe string) (map[string]interface{}, [][]byte) {
+	t.Helper()
+
+	encodedParts := strings.Split(jwe, ".")
+	if len(encodedParts) != 5 {
+		t.Fatalf("expected 5 parts, got %d", len(encodedParts))
+	}
+
+	parts := make([][]byte, len(encodedParts))
+	for i, encodedPart := range encodedParts {
+		part, err := base64.RawURLEncoding.DecodeString(encodedPart)
+		if err != nil {
+			t.Fatalf("part %d isn't base64url encoded: %v", i, err)
+		}
+		parts[i] = part
+	}
+
+	var header map[string]interface{}
+	if err := json.Unmarshal(parts[0], &header); err != nil {
+		t.Fatal(err)
+	}
+	return header, parts
+}
+
+// decryptContent decrypts the content of the JWE with the content encryption key
+func decryptContent(t *testing.T, cek []byte, enc, jwe string, parts [][]byte) string {
+	t.Helper()
+
+	encodedHeader := []byte(strings.Split(jwe, ".")[0])
+	iv, ciphertext, tag := parts[2], parts[3], parts[4]
+
+	if enc == "A128GCM" || enc == "A256GCM" {
+		block, err := aes.NewCipher(cek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), encodedHeader)
+		if err != nil {
+			t.Fatalf("%s: can't decrypt the content: %v", enc, err)
+		}
+		return string(plaintext)
+	}
+
+	// The second half of the key is the encryption key. Encrypting the plaintext again with the same IV
+	// has to result in the same ciphertext and authentication tag.
+	plaintext := decryptCBC(t, cek[len(cek)/2:], iv, ciphertext)
+	expectedCiphertext, expectedTag, err := encryptContentWithIV(cek, iv, enc, plaintext, encodedHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expectedCiphertext) != string(ciphertext) || string(expectedTag) != string(tag) {
+		t.Fatalf("%s: the authentication tag is invalid", enc)
+	}
+	return string(plaintext)
+}
+
+// decryptCBC decrypts the AES-CBC ciphertext and removes the PKCS #7 padding
+func decryptCBC(t *testing.T, key, iv, ciphertext []byte) []byte {
+	t.Helper()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	return plaintext[:len(plaintext)-padding]
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// PublicJWK is a public JSON Web Key as defined by RFC 7517
+type PublicJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseJWKSet parses a JSON Web Key Set and returns its keys
+func ParseJWKSet(data []byte) ([]PublicJWK, error) {
+	var jwks struct {
+		Keys []PublicJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	for _, key := range jwks.Keys {
+		if _, err := key.PublicKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return jwks.Keys, nil
+}
+
+// PublicKey returns the public key of the JWK
+func (k PublicJWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errors.New("invalid RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return publicKey, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// MatchesSigningAlgorithm returns true if the key can be used to verify signatures of the given JWS algorithm
+func (k PublicJWK) MatchesSigningAlgorithm(alg string) bool {
+	if k.Use != "" && k.Use != "sig" {
+		return false
+	}
+	if k.Alg != "" {
+		return k.Alg == alg
+	}
+
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		return k.Kty == "RSA"
+	case strings.HasPrefix(alg, "ES"):
+		return k.Kty == "EC"
+	case alg == "EdDSA":
+		return k.Kty == "OKP"
+	default:
+		return false
+	}
+}
+
+// Thumbprint returns the base64url encoded SHA-256 thumbprint of the JWK as defined by RFC 7638
+func (k PublicJWK) Thumbprint() (string, error) {
+	// The required members have to be in lexicographic order
+	var members []string
+	switch k.Kty {
+	case "RSA":
+		members = []string{"e", k.E, "kty", k.Kty, "n", k.N}
+	case "EC":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X, "y", k.Y}
+	case "OKP":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := 0; i < len(members); i += 2 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		name, _ := json.Marshal(members[i])
+		value, _ := json.Marshal(members[i+1])
+		sb.Write(name)
+		sb.WriteString(":")
+		sb.Write(value)
+	}
+	sb.WriteString("}")
+
+	hash := sha256.Sum256([]byte(sb.String()))
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
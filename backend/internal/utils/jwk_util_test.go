@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+)
+
+type thumbprintTestData struct {
+	name     string
+	key      PublicJWK
+	expected string
+}
+
+func TestThumbprint(t *testing.T) {
+	var testData = []thumbprintTestData{
+		{
+			// RFC 7638 section 3.1
+			name: "RSA",
+			key: PublicJWK{
+				Kty: "RSA",
+				N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+				E:   "AQAB",
+			},
+			expected: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// The optional members aren't part of the thumbprint
+			name: "RSA with optional members",
+			key: PublicJWK{
+				Kty: "RSA",
+				Kid: "2011-04-29",
+				Use: "sig",
+				Alg: "RS256",
+				N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+				E:   "AQAB",
+			},
+			expected: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// RFC 9449 section 6.1
+			name: "EC",
+			key: PublicJWK{
+				Kty: "EC",
+				Crv: "P-256",
+				X:   "l8tFrhx-34tV3hRICRDY9zCkDlpBhF42UQUfWVAWBFs",
+				Y:   "9VE4jf_Ok_o64zbTTlcuNJajHmt6v9TDVrU0CdvGRDA",
+			},
+			expected: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I",
+		},
+	}
+
+	for _, data := range testData {
+		got, err := data.key.Thumbprint()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", data.name, err)
+			continue
+		}
+		if got != data.expected {
+			t.Errorf("%s: expected '%s', got: '%s'", data.name, data.expected, got)
+		}
+	}
+}
+
+func TestThumbprintWithUnsupportedKeyType(t *testing.T) {
+	if _, err := (PublicJWK{Kty: "oct"}).Thumbprint(); err == nil {
+		t.Errorf("expected an error for a symmetric key")
+	}
+}
@@ -0,0 +1,14 @@
+CREATE TABLE oidc_refresh_tokens
+(
+    id         UUID         NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    token      VARCHAR(255) NOT NULL UNIQUE,
+    family_id  UUID         NOT NULL,
+    scope      TEXT         NOT NULL,
+    used       BOOLEAN DEFAULT FALSE NOT NULL,
+    expires_at TIMESTAMPTZ  NOT NULL,
+    user_id    UUID         NOT NULL REFERENCES users ON DELETE CASCADE,
+    client_id  UUID         NOT NULL REFERENCES oidc_clients ON DELETE CASCADE
+);
+
+CREATE INDEX idx_oidc_refresh_tokens_family_id ON oidc_refresh_tokens (family_id);
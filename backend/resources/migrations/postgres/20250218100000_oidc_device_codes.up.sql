@@ -0,0 +1,13 @@
+CREATE TABLE oidc_device_codes
+(
+    id             UUID         NOT NULL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    device_code    VARCHAR(255) NOT NULL UNIQUE,
+    user_code      VARCHAR(20)  NOT NULL UNIQUE,
+    scope          TEXT         NOT NULL,
+    is_authorized  BOOLEAN DEFAULT FALSE NOT NULL,
+    last_polled_at TIMESTAMPTZ,
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    user_id        UUID REFERENCES users ON DELETE CASCADE,
+    client_id      UUID         NOT NULL REFERENCES oidc_clients ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABLE oidc_revoked_tokens;
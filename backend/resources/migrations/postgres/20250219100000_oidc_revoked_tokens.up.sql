@@ -0,0 +1,6 @@
+CREATE TABLE oidc_revoked_tokens
+(
+    id         UUID        NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL
+);
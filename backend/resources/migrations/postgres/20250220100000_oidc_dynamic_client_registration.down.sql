@@ -0,0 +1,4 @@
+ALTER TABLE oidc_clients DROP COLUMN registration_access_token;
+ALTER TABLE oidc_clients DROP COLUMN logo_uri;
+
+DROP TABLE oidc_initial_access_tokens;
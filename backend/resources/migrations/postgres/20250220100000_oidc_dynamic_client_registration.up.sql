@@ -0,0 +1,12 @@
+CREATE TABLE oidc_initial_access_tokens
+(
+    id            UUID         NOT NULL PRIMARY KEY,
+    created_at    TIMESTAMPTZ,
+    token         VARCHAR(255) NOT NULL UNIQUE,
+    description   TEXT         NOT NULL DEFAULT '',
+    expires_at    TIMESTAMPTZ  NOT NULL,
+    created_by_id UUID         NOT NULL REFERENCES users ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_clients ADD COLUMN logo_uri TEXT;
+ALTER TABLE oidc_clients ADD COLUMN registration_access_token VARCHAR(255);
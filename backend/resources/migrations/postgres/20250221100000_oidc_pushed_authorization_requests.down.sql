@@ -0,0 +1,3 @@
+ALTER TABLE oidc_clients DROP COLUMN require_par;
+
+DROP TABLE oidc_pushed_authorization_requests;
@@ -0,0 +1,17 @@
+CREATE TABLE oidc_pushed_authorization_requests
+(
+    id                    UUID         NOT NULL PRIMARY KEY,
+    created_at            TIMESTAMPTZ,
+    request_uri           VARCHAR(255) NOT NULL UNIQUE,
+    response_type         TEXT         NOT NULL,
+    scope                 TEXT         NOT NULL,
+    callback_url          TEXT         NOT NULL,
+    state                 TEXT         NOT NULL DEFAULT '',
+    nonce                 TEXT         NOT NULL DEFAULT '',
+    code_challenge        TEXT         NOT NULL DEFAULT '',
+    code_challenge_method TEXT         NOT NULL DEFAULT '',
+    expires_at            TIMESTAMPTZ  NOT NULL,
+    client_id             UUID         NOT NULL REFERENCES oidc_clients ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_clients ADD COLUMN require_par BOOLEAN DEFAULT FALSE NOT NULL;
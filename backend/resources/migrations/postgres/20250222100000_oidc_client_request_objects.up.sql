@@ -0,0 +1,3 @@
+ALTER TABLE oidc_clients ADD COLUMN require_signed_request BOOLEAN DEFAULT FALSE NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN jwks TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN jwks_uri TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE oidc_clients DROP COLUMN jwt_secret;
+ALTER TABLE oidc_clients DROP COLUMN token_endpoint_auth_method;
+DROP TABLE oidc_client_assertions;
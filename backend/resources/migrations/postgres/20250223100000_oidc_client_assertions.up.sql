@@ -0,0 +1,13 @@
+CREATE TABLE oidc_client_assertions
+(
+    id         UUID         NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    jti        VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ  NOT NULL,
+    client_id  UUID         NOT NULL REFERENCES oidc_clients ON DELETE CASCADE,
+    UNIQUE (client_id, jti)
+);
+
+ALTER TABLE oidc_clients ADD COLUMN token_endpoint_auth_method TEXT DEFAULT 'client_secret_basic' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN jwt_secret TEXT DEFAULT '' NOT NULL;
+UPDATE oidc_clients SET token_endpoint_auth_method = 'none' WHERE is_public = TRUE;
@@ -0,0 +1 @@
+ALTER TABLE oidc_clients DROP COLUMN tls_client_auth_subject_dn;
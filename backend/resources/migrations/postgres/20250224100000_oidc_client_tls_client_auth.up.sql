@@ -0,0 +1 @@
+ALTER TABLE oidc_clients ADD COLUMN tls_client_auth_subject_dn TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE oidc_refresh_tokens DROP COLUMN dpop_jkt;
+ALTER TABLE oidc_clients DROP COLUMN require_dpop;
+DROP TABLE oidc_dpop_proofs;
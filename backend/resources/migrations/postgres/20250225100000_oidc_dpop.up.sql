@@ -0,0 +1,10 @@
+CREATE TABLE oidc_dpop_proofs
+(
+    id         UUID        NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    jti        VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE oidc_clients ADD COLUMN require_dpop BOOLEAN DEFAULT FALSE NOT NULL;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN dpop_jkt TEXT DEFAULT '' NOT NULL;
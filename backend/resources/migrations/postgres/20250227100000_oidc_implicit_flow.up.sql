@@ -0,0 +1 @@
+ALTER TABLE oidc_clients ADD COLUMN implicit_flow_enabled BOOLEAN DEFAULT FALSE NOT NULL;
\ No newline at end of file
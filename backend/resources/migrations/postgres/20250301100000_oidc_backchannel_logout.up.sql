@@ -0,0 +1,10 @@
+CREATE TABLE oidc_sessions
+(
+    id         UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    user_id    UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    client_id  UUID NOT NULL REFERENCES oidc_clients ON DELETE CASCADE,
+    UNIQUE (user_id, client_id)
+);
+
+ALTER TABLE oidc_clients ADD COLUMN backchannel_logout_uri TEXT DEFAULT '' NOT NULL;
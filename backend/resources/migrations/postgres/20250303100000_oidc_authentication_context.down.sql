@@ -0,0 +1,6 @@
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN login_hint;
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN max_age;
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN prompt;
+
+ALTER TABLE oidc_sessions DROP COLUMN amr;
+ALTER TABLE oidc_sessions DROP COLUMN auth_time;
@@ -0,0 +1,2 @@
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN claims;
+ALTER TABLE user_authorized_oidc_clients DROP COLUMN claims;
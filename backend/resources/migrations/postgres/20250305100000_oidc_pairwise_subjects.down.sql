@@ -0,0 +1,3 @@
+DROP TABLE oidc_pairwise_subjects;
+ALTER TABLE oidc_clients DROP COLUMN sector_identifier_uri;
+ALTER TABLE oidc_clients DROP COLUMN subject_type;
@@ -0,0 +1,6 @@
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN resources;
+ALTER TABLE oidc_refresh_tokens DROP COLUMN resources;
+ALTER TABLE oidc_authorization_codes DROP COLUMN resources;
+
+DROP TABLE oidc_resources_allowed_clients;
+DROP TABLE oidc_resources;
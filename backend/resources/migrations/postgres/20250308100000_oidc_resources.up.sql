@@ -0,0 +1,19 @@
+CREATE TABLE oidc_resources
+(
+    id          UUID        NOT NULL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    name        TEXT        NOT NULL,
+    identifier  TEXT        NOT NULL UNIQUE,
+    scopes      JSONB
+);
+
+CREATE TABLE oidc_resources_allowed_clients
+(
+    oidc_resource_id UUID NOT NULL REFERENCES oidc_resources ON DELETE CASCADE,
+    oidc_client_id   UUID NOT NULL REFERENCES oidc_clients ON DELETE CASCADE,
+    PRIMARY KEY (oidc_resource_id, oidc_client_id)
+);
+
+ALTER TABLE oidc_authorization_codes ADD COLUMN resources JSONB;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN resources JSONB;
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN resources JSONB;
@@ -0,0 +1,8 @@
+CREATE TABLE oidc_scopes
+(
+    id          UUID NOT NULL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    claims      JSONB
+);
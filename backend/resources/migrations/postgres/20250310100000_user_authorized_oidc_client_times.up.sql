@@ -0,0 +1,2 @@
+ALTER TABLE user_authorized_oidc_clients ADD COLUMN created_at TIMESTAMPTZ;
+ALTER TABLE user_authorized_oidc_clients ADD COLUMN last_authorized_at TIMESTAMPTZ;
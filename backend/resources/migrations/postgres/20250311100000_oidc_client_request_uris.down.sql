@@ -0,0 +1 @@
+ALTER TABLE oidc_clients DROP COLUMN request_uris;
\ No newline at end of file
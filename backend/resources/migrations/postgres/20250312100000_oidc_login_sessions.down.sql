@@ -0,0 +1,14 @@
+ALTER TABLE oidc_refresh_tokens DROP COLUMN session_id;
+ALTER TABLE oidc_authorization_codes DROP COLUMN session_id;
+
+DROP TABLE oidc_sessions;
+CREATE TABLE oidc_sessions
+(
+    id         UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    user_id    UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    client_id  UUID NOT NULL REFERENCES oidc_clients ON DELETE CASCADE,
+    auth_time  TIMESTAMPTZ,
+    amr        JSONB,
+    UNIQUE (user_id, client_id)
+);
@@ -0,0 +1,17 @@
+-- The sessions of the clients are tied to the login session of the user now. Existing sessions can't be assigned to a
+-- login session, so they are dropped with the table.
+DROP TABLE oidc_sessions;
+CREATE TABLE oidc_sessions
+(
+    id               UUID NOT NULL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    user_id          UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    client_id        UUID NOT NULL REFERENCES oidc_clients ON DELETE CASCADE,
+    login_session_id TEXT NOT NULL DEFAULT '',
+    auth_time        TIMESTAMPTZ,
+    amr              JSONB,
+    UNIQUE (user_id, client_id, login_session_id)
+);
+
+ALTER TABLE oidc_authorization_codes ADD COLUMN session_id TEXT;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN session_id TEXT;
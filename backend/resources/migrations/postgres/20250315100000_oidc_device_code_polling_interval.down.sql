@@ -0,0 +1 @@
+ALTER TABLE oidc_device_codes DROP COLUMN polling_interval;
@@ -0,0 +1,16 @@
+CREATE TABLE oidc_refresh_tokens
+(
+    id         TEXT     NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    token      TEXT     NOT NULL UNIQUE,
+    family_id  TEXT     NOT NULL,
+    scope      TEXT     NOT NULL,
+    used       NUMERIC DEFAULT FALSE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    user_id    TEXT     NOT NULL,
+    client_id  TEXT     NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_oidc_refresh_tokens_family_id ON oidc_refresh_tokens (family_id);
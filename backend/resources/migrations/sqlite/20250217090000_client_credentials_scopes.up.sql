@@ -0,0 +1 @@
+ALTER TABLE oidc_clients ADD COLUMN client_credentials_scopes BLOB;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE oidc_device_codes
+(
+    id             TEXT     NOT NULL PRIMARY KEY,
+    created_at     DATETIME,
+    device_code    TEXT     NOT NULL UNIQUE,
+    user_code      TEXT     NOT NULL UNIQUE,
+    scope          TEXT     NOT NULL,
+    is_authorized  NUMERIC DEFAULT FALSE NOT NULL,
+    last_polled_at DATETIME,
+    expires_at     DATETIME NOT NULL,
+    user_id        TEXT,
+    client_id      TEXT     NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+CREATE TABLE oidc_revoked_tokens
+(
+    id         TEXT     NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    expires_at DATETIME NOT NULL
+);
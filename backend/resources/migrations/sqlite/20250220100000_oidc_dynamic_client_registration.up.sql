@@ -0,0 +1,13 @@
+CREATE TABLE oidc_initial_access_tokens
+(
+    id            TEXT     NOT NULL PRIMARY KEY,
+    created_at    DATETIME,
+    token         TEXT     NOT NULL UNIQUE,
+    description   TEXT     NOT NULL DEFAULT '',
+    expires_at    DATETIME NOT NULL,
+    created_by_id TEXT     NOT NULL,
+    FOREIGN KEY (created_by_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_clients ADD COLUMN logo_uri TEXT;
+ALTER TABLE oidc_clients ADD COLUMN registration_access_token TEXT;
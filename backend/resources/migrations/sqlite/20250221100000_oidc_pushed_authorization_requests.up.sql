@@ -0,0 +1,18 @@
+CREATE TABLE oidc_pushed_authorization_requests
+(
+    id                    TEXT     NOT NULL PRIMARY KEY,
+    created_at            DATETIME,
+    request_uri           TEXT     NOT NULL UNIQUE,
+    response_type         TEXT     NOT NULL,
+    scope                 TEXT     NOT NULL,
+    callback_url          TEXT     NOT NULL,
+    state                 TEXT     NOT NULL DEFAULT '',
+    nonce                 TEXT     NOT NULL DEFAULT '',
+    code_challenge        TEXT     NOT NULL DEFAULT '',
+    code_challenge_method TEXT     NOT NULL DEFAULT '',
+    expires_at            DATETIME NOT NULL,
+    client_id             TEXT     NOT NULL,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_clients ADD COLUMN require_par NUMERIC DEFAULT FALSE NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE oidc_clients DROP COLUMN jwks_uri;
+ALTER TABLE oidc_clients DROP COLUMN jwks;
+ALTER TABLE oidc_clients DROP COLUMN require_signed_request;
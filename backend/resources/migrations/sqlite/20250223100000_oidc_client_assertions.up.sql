@@ -0,0 +1,14 @@
+CREATE TABLE oidc_client_assertions
+(
+    id         TEXT     NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    jti        TEXT     NOT NULL,
+    expires_at DATETIME NOT NULL,
+    client_id  TEXT     NOT NULL,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE,
+    UNIQUE (client_id, jti)
+);
+
+ALTER TABLE oidc_clients ADD COLUMN token_endpoint_auth_method TEXT DEFAULT 'client_secret_basic' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN jwt_secret TEXT DEFAULT '' NOT NULL;
+UPDATE oidc_clients SET token_endpoint_auth_method = 'none' WHERE is_public = TRUE;
@@ -0,0 +1,10 @@
+CREATE TABLE oidc_dpop_proofs
+(
+    id         TEXT     NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    jti        TEXT     NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL
+);
+
+ALTER TABLE oidc_clients ADD COLUMN require_dpop NUMERIC DEFAULT FALSE NOT NULL;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN dpop_jkt TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE oidc_clients DROP COLUMN implicit_flow_enabled;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE oidc_pushed_authorization_requests DROP COLUMN response_mode;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN response_mode TEXT DEFAULT '' NOT NULL;
\ No newline at end of file
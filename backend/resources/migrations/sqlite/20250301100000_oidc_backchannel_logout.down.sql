@@ -0,0 +1,2 @@
+ALTER TABLE oidc_clients DROP COLUMN backchannel_logout_uri;
+DROP TABLE oidc_sessions;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE oidc_sessions
+(
+    id         TEXT NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    user_id    TEXT NOT NULL,
+    client_id  TEXT NOT NULL,
+    UNIQUE (user_id, client_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_clients ADD COLUMN backchannel_logout_uri TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE oidc_clients ADD COLUMN frontchannel_logout_uri TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN frontchannel_logout_session_required NUMERIC DEFAULT FALSE NOT NULL;
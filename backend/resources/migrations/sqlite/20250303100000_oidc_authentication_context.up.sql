@@ -0,0 +1,6 @@
+ALTER TABLE oidc_sessions ADD COLUMN auth_time DATETIME;
+ALTER TABLE oidc_sessions ADD COLUMN amr BLOB;
+
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN prompt TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN max_age INTEGER;
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN login_hint TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE user_authorized_oidc_clients ADD COLUMN claims TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN claims TEXT DEFAULT '' NOT NULL;
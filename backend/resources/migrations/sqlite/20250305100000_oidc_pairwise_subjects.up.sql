@@ -0,0 +1,10 @@
+ALTER TABLE oidc_clients ADD COLUMN subject_type TEXT DEFAULT 'public' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN sector_identifier_uri TEXT DEFAULT '' NOT NULL;
+
+CREATE TABLE oidc_pairwise_subjects
+(
+    subject           TEXT NOT NULL PRIMARY KEY,
+    sector_identifier TEXT NOT NULL,
+    user_id           TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE oidc_clients DROP COLUMN authorization_code_lifetime;
+ALTER TABLE oidc_clients DROP COLUMN refresh_token_lifetime;
+ALTER TABLE oidc_clients DROP COLUMN access_token_lifetime;
+ALTER TABLE oidc_clients DROP COLUMN id_token_lifetime;
@@ -0,0 +1,4 @@
+ALTER TABLE oidc_clients ADD COLUMN id_token_lifetime INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN access_token_lifetime INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN refresh_token_lifetime INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN authorization_code_lifetime INTEGER DEFAULT 0 NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE oidc_clients ADD COLUMN id_token_encrypted_response_alg TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN id_token_encrypted_response_enc TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN userinfo_signed_response_alg TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN userinfo_encrypted_response_alg TEXT DEFAULT '' NOT NULL;
+ALTER TABLE oidc_clients ADD COLUMN userinfo_encrypted_response_enc TEXT DEFAULT '' NOT NULL;
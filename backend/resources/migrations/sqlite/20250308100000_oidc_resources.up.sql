@@ -0,0 +1,21 @@
+CREATE TABLE oidc_resources
+(
+    id          TEXT     NOT NULL PRIMARY KEY,
+    created_at  DATETIME,
+    name        TEXT     NOT NULL,
+    identifier  TEXT     NOT NULL UNIQUE,
+    scopes      BLOB
+);
+
+CREATE TABLE oidc_resources_allowed_clients
+(
+    oidc_resource_id TEXT NOT NULL,
+    oidc_client_id   TEXT NOT NULL,
+    PRIMARY KEY (oidc_resource_id, oidc_client_id),
+    FOREIGN KEY (oidc_resource_id) REFERENCES oidc_resources (id) ON DELETE CASCADE,
+    FOREIGN KEY (oidc_client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_authorization_codes ADD COLUMN resources BLOB;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN resources BLOB;
+ALTER TABLE oidc_pushed_authorization_requests ADD COLUMN resources BLOB;
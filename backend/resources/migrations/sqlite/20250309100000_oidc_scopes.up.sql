@@ -0,0 +1,8 @@
+CREATE TABLE oidc_scopes
+(
+    id          TEXT NOT NULL PRIMARY KEY,
+    created_at  DATETIME,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    claims      BLOB
+);
@@ -0,0 +1,2 @@
+ALTER TABLE user_authorized_oidc_clients DROP COLUMN last_authorized_at;
+ALTER TABLE user_authorized_oidc_clients DROP COLUMN created_at;
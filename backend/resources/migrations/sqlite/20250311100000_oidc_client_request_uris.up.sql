@@ -0,0 +1 @@
+ALTER TABLE oidc_clients ADD COLUMN request_uris BLOB;
\ No newline at end of file
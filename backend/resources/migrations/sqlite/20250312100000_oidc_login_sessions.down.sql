@@ -0,0 +1,16 @@
+ALTER TABLE oidc_refresh_tokens DROP COLUMN session_id;
+ALTER TABLE oidc_authorization_codes DROP COLUMN session_id;
+
+DROP TABLE oidc_sessions;
+CREATE TABLE oidc_sessions
+(
+    id         TEXT NOT NULL PRIMARY KEY,
+    created_at DATETIME,
+    user_id    TEXT NOT NULL,
+    client_id  TEXT NOT NULL,
+    auth_time  DATETIME,
+    amr        BLOB,
+    UNIQUE (user_id, client_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
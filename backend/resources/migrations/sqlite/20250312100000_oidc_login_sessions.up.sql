@@ -0,0 +1,19 @@
+-- The sessions of the clients are tied to the login session of the user now. Existing sessions can't be assigned to a
+-- login session, so they are dropped with the table.
+DROP TABLE oidc_sessions;
+CREATE TABLE oidc_sessions
+(
+    id               TEXT NOT NULL PRIMARY KEY,
+    created_at       DATETIME,
+    user_id          TEXT NOT NULL,
+    client_id        TEXT NOT NULL,
+    login_session_id TEXT NOT NULL DEFAULT '',
+    auth_time        DATETIME,
+    amr              BLOB,
+    UNIQUE (user_id, client_id, login_session_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (id) ON DELETE CASCADE
+);
+
+ALTER TABLE oidc_authorization_codes ADD COLUMN session_id TEXT;
+ALTER TABLE oidc_refresh_tokens ADD COLUMN session_id TEXT;
@@ -0,0 +1 @@
+ALTER TABLE user_authorized_oidc_clients DROP COLUMN consented_claims;
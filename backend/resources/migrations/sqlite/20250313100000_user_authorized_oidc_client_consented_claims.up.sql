@@ -0,0 +1 @@
+ALTER TABLE user_authorized_oidc_clients ADD COLUMN consented_claims BLOB;
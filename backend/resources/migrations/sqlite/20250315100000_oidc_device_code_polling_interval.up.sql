@@ -0,0 +1 @@
+ALTER TABLE oidc_device_codes ADD COLUMN polling_interval INTEGER DEFAULT 5 NOT NULL;
@@ -1,8 +1,17 @@
 import type {
+	AuthorizationRequestParameters,
 	AuthorizeResponse,
+	AuthorizedOidcClient,
+	DeviceCodeInfo,
 	OidcClient,
 	OidcClientCreate,
-	OidcClientWithAllowedUserGroups
+	OidcClientWithAllowedUserGroups,
+	OidcInitialAccessToken,
+	OidcInitialAccessTokenWithToken,
+	OidcResource,
+	OidcResourceCreate,
+	OidcScope,
+	OidcScopeCreate
 } from '$lib/types/oidc.type';
 import type { Paginated, SearchPaginationSortRequest } from '$lib/types/pagination.type';
 import APIService from './api-service';
@@ -10,33 +19,70 @@ import APIService from './api-service';
 class OidcService extends APIService {
 	async authorize(
 		clientId: string,
+		responseType: string,
+		responseMode: string | undefined,
 		scope: string,
 		callbackURL: string,
+		state?: string,
 		nonce?: string,
 		codeChallenge?: string,
-		codeChallengeMethod?: string
+		codeChallengeMethod?: string,
+		prompt?: string,
+		maxAge?: number,
+		loginHint?: string,
+		claims?: string,
+		resource?: string[],
+		request?: string,
+		requestUri?: string
 	) {
 		const res = await this.api.post('/oidc/authorize', {
+			responseType,
+			responseMode,
 			scope,
 			nonce,
 			callbackURL,
+			state,
 			clientId,
 			codeChallenge,
-			codeChallengeMethod
+			codeChallengeMethod,
+			prompt,
+			maxAge,
+			loginHint,
+			claims,
+			resource,
+			request,
+			requestUri
 		});
 
 		return res.data as AuthorizeResponse;
 	}
 
-	async isAuthorizationRequired(clientId: string, scope: string) {
+	async resolveAuthorizationRequest(params: URLSearchParams) {
+		const res = await this.api.get('/oidc/authorization-request', { params });
+		return res.data as AuthorizationRequestParameters;
+	}
+
+	async isAuthorizationRequired(clientId: string, scope: string, claims?: string) {
 		const res = await this.api.post('/oidc/authorization-required', {
 			scope,
+			claims,
 			clientId
 		});
 
 		return res.data.authorizationRequired as boolean;
 	}
 
+	async getDeviceCodeInfo(userCode: string) {
+		const res = await this.api.get('/oidc/device/info', {
+			params: { code: userCode }
+		});
+		return res.data as DeviceCodeInfo;
+	}
+
+	async verifyDeviceCode(userCode: string) {
+		await this.api.post('/oidc/device/verify', { userCode });
+	}
+
 	async listClients(options?: SearchPaginationSortRequest) {
 		const res = await this.api.get('/oidc/clients', {
 			params: options
@@ -87,6 +133,68 @@ class OidcService extends APIService {
 		const res = await this.api.put(`/oidc/clients/${id}/allowed-user-groups`, { userGroupIds });
 		return res.data as OidcClientWithAllowedUserGroups;
 	}
+
+	async listInitialAccessTokens() {
+		return (await this.api.get('/oidc/initial-access-tokens')).data as OidcInitialAccessToken[];
+	}
+
+	async createInitialAccessToken(description: string, expiresAt: Date) {
+		const res = await this.api.post('/oidc/initial-access-tokens', { description, expiresAt });
+		return res.data as OidcInitialAccessTokenWithToken;
+	}
+
+	async removeInitialAccessToken(id: string) {
+		await this.api.delete(`/oidc/initial-access-tokens/${id}`);
+	}
+
+	async listResources() {
+		return (await this.api.get('/oidc/resources')).data as OidcResource[];
+	}
+
+	async createResource(resource: OidcResourceCreate) {
+		return (await this.api.post('/oidc/resources', resource)).data as OidcResource;
+	}
+
+	async updateResource(id: string, resource: OidcResourceCreate) {
+		return (await this.api.put(`/oidc/resources/${id}`, resource)).data as OidcResource;
+	}
+
+	async removeResource(id: string) {
+		await this.api.delete(`/oidc/resources/${id}`);
+	}
+
+	async updateResourceAllowedClients(id: string, clientIds: string[]) {
+		const res = await this.api.put(`/oidc/resources/${id}/allowed-clients`, { clientIds });
+		return res.data as OidcResource;
+	}
+
+	async listScopes() {
+		return (await this.api.get('/oidc/scopes')).data as OidcScope[];
+	}
+
+	async createScope(scope: OidcScopeCreate) {
+		return (await this.api.post('/oidc/scopes', scope)).data as OidcScope;
+	}
+
+	async updateScope(id: string, scope: OidcScopeCreate) {
+		return (await this.api.put(`/oidc/scopes/${id}`, scope)).data as OidcScope;
+	}
+
+	async removeScope(id: string) {
+		await this.api.delete(`/oidc/scopes/${id}`);
+	}
+
+	async listAuthorizedClients(userId = 'me') {
+		return (await this.api.get(`/users/${userId}/authorized-clients`)).data as AuthorizedOidcClient[];
+	}
+
+	async revokeAuthorizedClient(clientId: string, userId = 'me') {
+		await this.api.delete(`/users/${userId}/authorized-clients/${clientId}`);
+	}
+
+	async revokeUserSessions(userId: string) {
+		await this.api.delete(`/oidc/users/${userId}/sessions`);
+	}
 }
 
 export default OidcService;
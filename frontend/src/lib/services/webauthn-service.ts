@@ -22,8 +22,9 @@ class WebAuthnService extends APIService {
 	}
 
 	async logout() {
-		await this.api.post(`/webauthn/logout`);
+		const res = await this.api.post(`/webauthn/logout`);
 		userStore.clearUser();
+		return res.data.frontchannelLogoutUrls as string[];
 	}
 
 	async listCredentials() {
@@ -9,6 +9,11 @@ export type AllAppConfig = AppConfig & {
 	// General
 	sessionDuration: number;
 	emailsVerified: boolean;
+	// OIDC
+	idTokenLifetime: number;
+	accessTokenLifetime: number;
+	refreshTokenLifetime: number;
+	authorizationCodeLifetime: number;
 	// Email
 	smtpHost: string;
 	smtpPort: number;
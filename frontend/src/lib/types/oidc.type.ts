@@ -9,6 +9,32 @@ export type OidcClient = {
 	hasLogo: boolean;
 	isPublic: boolean;
 	pkceEnabled: boolean;
+	clientCredentialsScopes: string[];
+	tokenExchangeAudiences: string[];
+	requirePar: boolean;
+	requireSignedRequest: boolean;
+	requestUris: string[];
+	requireDpop: boolean;
+	implicitFlowEnabled: boolean;
+	jwks: string;
+	jwksUri: string;
+	tokenEndpointAuthMethod: string;
+	tlsClientAuthSubjectDn: string;
+	backchannelLogoutUri: string;
+	frontchannelLogoutUri: string;
+	frontchannelLogoutSessionRequired: boolean;
+	subjectType: string;
+	sectorIdentifierUri: string;
+	idTokenSignedResponseAlg: string;
+	idTokenEncryptedResponseAlg: string;
+	idTokenEncryptedResponseEnc: string;
+	userinfoSignedResponseAlg: string;
+	userinfoEncryptedResponseAlg: string;
+	userinfoEncryptedResponseEnc: string;
+	idTokenLifetime: number;
+	accessTokenLifetime: number;
+	refreshTokenLifetime: number;
+	authorizationCodeLifetime: number;
 };
 
 export type OidcClientWithAllowedUserGroups = OidcClient & {
@@ -22,6 +48,68 @@ export type OidcClientCreateWithLogo = OidcClientCreate & {
 };
 
 export type AuthorizeResponse = {
-	code: string;
 	callbackURL: string;
+	responseMode: 'query' | 'fragment' | 'form_post';
+	parameters: Record<string, string>;
+	formPostHtml?: string;
+};
+
+export type DeviceCodeInfo = {
+	client: OidcClient;
+	scope: string;
+	authorizationRequired: boolean;
+};
+
+export type OidcInitialAccessToken = {
+	id: string;
+	description: string;
+	expiresAt: string;
+	createdAt: string;
+};
+
+export type OidcInitialAccessTokenWithToken = OidcInitialAccessToken & {
+	token: string;
+};
+
+export type OidcResource = {
+	id: string;
+	name: string;
+	identifier: string;
+	scopes: string[];
+	allowedClients: Pick<OidcClient, 'id' | 'name' | 'hasLogo'>[];
+	createdAt: string;
+};
+
+export type OidcResourceCreate = Pick<OidcResource, 'name' | 'identifier' | 'scopes'>;
+
+export type OidcScope = {
+	id: string;
+	name: string;
+	description: string;
+	claims: string[];
+	createdAt: string;
+};
+
+export type OidcScopeCreate = Pick<OidcScope, 'name' | 'description' | 'claims'>;
+
+export type AuthorizedOidcClient = {
+	scope: string;
+	client: Pick<OidcClient, 'id' | 'name' | 'hasLogo'>;
+	createdAt: string | null;
+	lastAuthorizedAt: string | null;
+};
+
+export type AuthorizationRequestParameters = {
+	responseType: string;
+	responseMode: string;
+	scope: string;
+	callbackURL: string;
+	state: string;
+	nonce: string;
+	codeChallenge: string;
+	codeChallengeMethod: string;
+	prompt: string;
+	maxAge: number | null;
+	loginHint: string;
+	claims: string;
 };
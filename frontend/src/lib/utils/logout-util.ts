@@ -0,0 +1,25 @@
+// Loads the front-channel logout URLs of the clients in hidden iframes, so that the clients can end their sessions.
+// Resolves once all iframes are loaded or after the timeout, because a client might never respond.
+export function loadFrontchannelLogoutUrls(urls: string[], timeout = 5000) {
+	if (urls.length === 0) return Promise.resolve();
+
+	return new Promise<void>((resolve) => {
+		let pendingFrames = urls.length;
+		const frames = urls.map((url) => {
+			const frame = document.createElement('iframe');
+			frame.src = url;
+			frame.style.display = 'none';
+			frame.addEventListener('load', () => --pendingFrames === 0 && done());
+			return frame;
+		});
+
+		const timer = setTimeout(done, timeout);
+		function done() {
+			clearTimeout(timer);
+			frames.forEach((frame) => frame.remove());
+			resolve();
+		}
+
+		document.body.append(...frames);
+	});
+}
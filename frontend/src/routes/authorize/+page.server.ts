@@ -8,13 +8,50 @@ export const load: PageServerLoad = async ({ url, cookies }) => {
 
 	const client = await oidcService.getClient(clientId!);
 
+	// The parameters were pushed by the client before or are contained in a signed request object
+	const request = url.searchParams.get('request') || undefined;
+	const requestUri = url.searchParams.get('request_uri') || undefined;
+	if (request || requestUri) {
+		const parameters = await oidcService.resolveAuthorizationRequest(url.searchParams);
+		return {
+			responseType: parameters.responseType,
+			responseMode: parameters.responseMode || undefined,
+			scope: parameters.scope,
+			nonce: parameters.nonce || undefined,
+			state: parameters.state,
+			callbackURL: parameters.callbackURL,
+			client,
+			codeChallenge: parameters.codeChallenge,
+			codeChallengeMethod: parameters.codeChallengeMethod,
+			prompt: parameters.prompt || undefined,
+			maxAge: parameters.maxAge ?? undefined,
+			loginHint: parameters.loginHint || undefined,
+			// The claims and resources are read from the pushed request or the request object when the client is authorized
+			claims: undefined,
+			requestedClaims: parameters.claims || undefined,
+			resource: undefined,
+			request,
+			requestUri
+		};
+	}
+
 	return {
+		responseType: url.searchParams.get('response_type') || 'code',
+		responseMode: url.searchParams.get('response_mode') || undefined,
 		scope: url.searchParams.get('scope')!,
 		nonce: url.searchParams.get('nonce') || undefined,
 		state: url.searchParams.get('state')!,
 		callbackURL: url.searchParams.get('redirect_uri')!,
 		client,
 		codeChallenge: url.searchParams.get('code_challenge')!,
-		codeChallengeMethod: url.searchParams.get('code_challenge_method')!
+		codeChallengeMethod: url.searchParams.get('code_challenge_method')!,
+		prompt: url.searchParams.get('prompt') || undefined,
+		maxAge: url.searchParams.has('max_age') ? Number(url.searchParams.get('max_age')) : undefined,
+		loginHint: url.searchParams.get('login_hint') || undefined,
+		claims: url.searchParams.get('claims') || undefined,
+		requestedClaims: url.searchParams.get('claims') || undefined,
+		resource: url.searchParams.getAll('resource'),
+		request: undefined,
+		requestUri: undefined
 	};
 };
@@ -25,12 +25,14 @@ export const oidcClients = {
 	nextcloud: {
 		id: '3654a746-35d4-4321-ac61-0bdcff2b4055',
 		name: 'Nextcloud',
+		secret: 'w2mUeZISmEvIDMEDvpY0PnxQIpj1m3zY',
 		callbackUrl: 'http://nextcloud/auth/callback',
 		logoutCallbackUrl: 'http://nextcloud/auth/logout/callback'
 	},
 	immich: {
 		id: '606c7782-f2b1-49e5-8ea9-26eb1b06d018',
 		name: 'Immich',
+		secret: 'PYjrE9u4v9GVqXKi52eur0eb2Ci4kc0x',
 		callbackUrl: 'http://immich/auth/callback'
 	},
 	pingvinShare: {
@@ -1,5 +1,21 @@
-import test, { expect } from '@playwright/test';
-import { oidcClients } from './data';
+import test, { expect, type Page } from '@playwright/test';
+import {
+	constants,
+	createDecipheriv,
+	createHash,
+	createHmac,
+	createPublicKey,
+	generateKeyPairSync,
+	privateDecrypt,
+	randomUUID,
+	sign,
+	verify,
+	KeyObject,
+	X509Certificate
+} from 'node:crypto';
+import { readFileSync } from 'node:fs';
+import { createServer } from 'node:http';
+import { oidcClients, userGroups, users } from './data';
 import { cleanupBackend } from './utils/cleanup.util';
 import passkeyUtil from './utils/passkey.util';
 
@@ -134,3 +150,1456 @@ test('End session with id token hint redirects to callback URL', async ({ page }
 
 	expect(redirectedCorrectly).toBeTruthy();
 });
+
+test('Refresh token is rotated', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	expect(tokens.refresh_token).toBeTruthy();
+
+	const refreshedTokens = await requestTokens(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token
+	});
+	expect(refreshedTokens.access_token).toBeTruthy();
+	expect(refreshedTokens.refresh_token).toBeTruthy();
+	expect(refreshedTokens.refresh_token).not.toBe(tokens.refresh_token);
+});
+
+test('Reused refresh token revokes the token family', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const refreshedTokens = await requestTokens(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token
+	});
+
+	const reuse = await postToken(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token
+	});
+	expect(reuse.status()).toBe(400);
+	expect((await reuse.json()).error).toBe('invalid_grant');
+
+	const refresh = await postToken(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: refreshedTokens.refresh_token
+	});
+	expect(refresh.status()).toBe(400);
+});
+
+test('Refresh token cannot widen the granted scope', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	const res = await postToken(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token,
+		scope: 'openid email'
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_scope');
+});
+
+test('Token endpoint returns OAuth errors for invalid grants', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+
+	let res = await postToken(page, client, { grant_type: 'password' });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('unsupported_grant_type');
+
+	res = await postToken(page, client, { grant_type: 'authorization_code', code: 'invalid' });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_grant');
+});
+
+test('Client credentials grant issues an access token for the client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { clientCredentialsScopes: ['files:read', 'files:write'] });
+
+	const tokens = await requestTokens(page, client, {
+		grant_type: 'client_credentials',
+		scope: 'files:read'
+	});
+	expect(tokens.token_type).toBe('Bearer');
+	expect(tokens.scope).toBe('files:read');
+	expect(tokens.refresh_token).toBeUndefined();
+	expect(decodeJwt(tokens.access_token).sub).toBe(client.id);
+});
+
+test('Client credentials grant requires allowed scopes', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	let res = await postToken(page, client, { grant_type: 'client_credentials' });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('unauthorized_client');
+
+	await updateClient(page, client, { clientCredentialsScopes: ['files:read'] });
+	res = await postToken(page, client, { grant_type: 'client_credentials', scope: 'files:write' });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_scope');
+});
+
+test('Device authorization grant issues tokens once the user approves', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const authorization = await requestDeviceAuthorization(page, client);
+	expect(authorization.interval).toBe(5);
+	expect(authorization.verification_uri_complete).toContain(authorization.user_code);
+
+	const form = {
+		grant_type: 'urn:ietf:params:oauth:grant-type:device_code',
+		device_code: authorization.device_code
+	};
+	const pending = await postToken(page, client, form);
+	expect((await pending.json()).error).toBe('authorization_pending');
+
+	const info = await page.request.get('/api/oidc/device/info', {
+		params: { code: authorization.user_code }
+	});
+	expect((await info.json()).client.id).toBe(client.id);
+	const verify = await page.request.post('/api/oidc/device/verify', {
+		data: { userCode: authorization.user_code }
+	});
+	expect(verify.status()).toBe(204);
+
+	const tokens = await requestTokens(page, client, form);
+	expect(tokens.id_token).toBeTruthy();
+
+	// The device code can only be redeemed once
+	const reuse = await postToken(page, client, form);
+	expect((await reuse.json()).error).toBe('invalid_grant');
+});
+
+test('Device authorization grant slows down clients that poll too fast', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const authorization = await requestDeviceAuthorization(page, client);
+	const form = {
+		grant_type: 'urn:ietf:params:oauth:grant-type:device_code',
+		device_code: authorization.device_code
+	};
+
+	await postToken(page, client, form);
+	const res = await postToken(page, client, form);
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('slow_down');
+
+	const invalid = await page.request.post('/api/oidc/device/verify', {
+		data: { userCode: 'BCDF-GHJK' }
+	});
+	expect(invalid.status()).toBe(400);
+});
+
+test('Introspection returns the state of access and refresh tokens', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	const accessToken = await introspect(page, client, { token: tokens.access_token });
+	expect(accessToken.active).toBe(true);
+	expect(accessToken.client_id).toBe(client.id);
+	expect(accessToken.sub).toBe(users.tim.id);
+	expect(accessToken.scope).toBe('openid offline_access');
+
+	const refreshToken = await introspect(page, client, {
+		token: tokens.refresh_token,
+		token_type_hint: 'refresh_token'
+	});
+	expect(refreshToken.active).toBe(true);
+	expect(refreshToken.client_id).toBe(client.id);
+});
+
+test('Introspection reports invalid tokens as inactive', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	expect(await introspect(page, client, { token: 'invalid' })).toEqual({ active: false });
+
+	// Clients can't introspect the refresh tokens of other clients
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const refreshToken = await introspect(page, oidcClients.immich, {
+		token: tokens.refresh_token,
+		token_type_hint: 'refresh_token'
+	});
+	expect(refreshToken.active).toBe(false);
+
+	const res = await page.request.post('/api/oidc/introspect', { form: { token: 'invalid' } });
+	expect(res.ok()).toBe(false);
+});
+
+test('Revoked tokens can no longer be used', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	expect((await revoke(page, client, { token: tokens.access_token })).status()).toBe(200);
+	expect((await introspect(page, client, { token: tokens.access_token })).active).toBe(false);
+	const userinfo = await page.request.get('/api/oidc/userinfo', {
+		headers: { Authorization: `Bearer ${tokens.access_token}` }
+	});
+	expect(userinfo.ok()).toBe(false);
+
+	const revokeRefreshToken = await revoke(page, client, {
+		token: tokens.refresh_token,
+		token_type_hint: 'refresh_token'
+	});
+	expect(revokeRefreshToken.status()).toBe(200);
+	const refresh = await postToken(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token
+	});
+	expect((await refresh.json()).error).toBe('invalid_grant');
+});
+
+test('Clients cannot revoke the tokens of other clients', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	// Unknown tokens are ignored as required by RFC 7009
+	const res = await revoke(page, oidcClients.immich, { token: tokens.access_token });
+	expect(res.status()).toBe(200);
+	expect((await introspect(page, client, { token: tokens.access_token })).active).toBe(true);
+
+	const unauthenticated = await page.request.post('/api/oidc/revoke', {
+		form: { token: tokens.access_token }
+	});
+	expect(unauthenticated.ok()).toBe(false);
+});
+
+test('Dynamic client registration creates a manageable client', async ({ page }) => {
+	const initialAccessToken = await createInitialAccessToken(page);
+	const res = await page.request.post('/api/oidc/register', {
+		headers: { Authorization: `Bearer ${initialAccessToken}` },
+		data: { client_name: 'Registered App', redirect_uris: ['https://app.example/callback'] }
+	});
+	expect(res.status()).toBe(201);
+	const registration = await res.json();
+	expect(registration.client_secret).toBeTruthy();
+	expect(registration.redirect_uris).toEqual(['https://app.example/callback']);
+
+	const headers = { Authorization: `Bearer ${registration.registration_access_token}` };
+	const client = await page.request.get(`/api/oidc/register/${registration.client_id}`, {
+		headers
+	});
+	expect((await client.json()).client_name).toBe('Registered App');
+
+	// Browser-based clients need the preflight request to allow the deletion
+	const preflight = await page.request.fetch(`/api/oidc/register/${registration.client_id}`, {
+		method: 'OPTIONS'
+	});
+	expect(preflight.headers()['access-control-allow-methods']).toContain('DELETE');
+
+	const deletion = await page.request.delete(`/api/oidc/register/${registration.client_id}`, {
+		headers
+	});
+	expect(deletion.status()).toBe(204);
+	const deletedClient = await page.request.get(`/api/oidc/register/${registration.client_id}`, {
+		headers
+	});
+	expect(deletedClient.ok()).toBe(false);
+});
+
+test('Dynamic client registration rejects invalid requests', async ({ page }) => {
+	const data = { client_name: 'Registered App', redirect_uris: ['https://app.example/callback'] };
+	const unauthenticated = await page.request.post('/api/oidc/register', { data });
+	expect(unauthenticated.status()).toBe(401);
+
+	// Only HTTPS and loopback redirect URIs are accepted
+	for (const redirectUri of ['javascript:alert(1)', 'http://app.example/callback']) {
+		const res = await page.request.post('/api/oidc/register', {
+			headers: { Authorization: `Bearer ${await createInitialAccessToken(page)}` },
+			data: { ...data, redirect_uris: [redirectUri] }
+		});
+		expect(res.status()).toBe(400);
+		expect((await res.json()).error).toBe('invalid_redirect_uri');
+	}
+});
+
+test('Pushed authorization request is used to authorize the client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { requirePar: true });
+
+	const requestUri = await pushAuthorizationRequest(page, client, {
+		response_type: 'code',
+		scope: 'openid profile',
+		redirect_uri: client.callbackUrl,
+		state: 'pushed-state',
+		nonce: 'pushed-nonce'
+	});
+	const parameters = await page.request.get('/api/oidc/authorization-request', {
+		params: { client_id: client.id, request_uri: requestUri }
+	});
+	expect((await parameters.json()).state).toBe('pushed-state');
+
+	const { code, state } = await authorize(page, client, { scope: 'openid', requestUri });
+	expect(state).toBe('pushed-state');
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	expect(decodeJwt(tokens.id_token).nonce).toBe('pushed-nonce');
+});
+
+test('Pushed authorization request is required if the client enforces it', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { requirePar: true });
+
+	const res = await page.request.post('/api/oidc/authorize', {
+		data: { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+
+	// The request URI can't be used by another client
+	const requestUri = await pushAuthorizationRequest(page, client, {
+		response_type: 'code',
+		scope: 'openid',
+		redirect_uri: client.callbackUrl
+	});
+	const otherClient = await page.request.post('/api/oidc/authorize', {
+		data: { clientID: oidcClients.immich.id, scope: 'openid', requestUri }
+	});
+	expect(otherClient.status()).toBe(400);
+});
+
+test('Signed request object overwrites the authorization parameters', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const key = await registerClientKey(page, client, { requireSignedRequest: true });
+
+	const request = signJwt(key, {
+		iss: client.id,
+		aud: await issuer(page),
+		exp: Math.floor(Date.now() / 1000) + 60,
+		redirect_uri: client.callbackUrl,
+		state: 'signed-state',
+		nonce: 'signed-nonce'
+	});
+	const { code, state } = await authorize(page, client, { state: 'plain-state', request });
+	expect(state).toBe('signed-state');
+
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	expect(decodeJwt(tokens.id_token).nonce).toBe('signed-nonce');
+});
+
+test('Signed request object is required and verified', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await registerClientKey(page, client, { requireSignedRequest: true });
+
+	let res = await page.request.post('/api/oidc/authorize', {
+		data: { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+
+	// Neither a key the client did not register nor an expired request object is accepted
+	const { privateKey: otherKey } = generateKeyPairSync('rsa', { modulusLength: 2048 });
+	const now = Math.floor(Date.now() / 1000);
+	const claims = { iss: client.id, aud: await issuer(page), exp: now + 60 };
+	const expiredClaims = { ...claims, exp: now - 60 };
+	const key = await registerClientKey(page, client, { requireSignedRequest: true });
+	for (const request of [signJwt(otherKey, claims), signJwt(key, expiredClaims)]) {
+		res = await page.request.post('/api/oidc/authorize', {
+			data: { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl, request }
+		});
+		expect(res.status()).toBe(400);
+		expect((await res.json()).error).toBe('invalid_request_object');
+	}
+});
+
+test('Client authentication with a wrong secret fails with invalid_client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client);
+
+	const res = await postToken(
+		page,
+		{ ...client, secret: 'wrong' },
+		{ grant_type: 'authorization_code', code }
+	);
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('invalid_client');
+});
+
+test('Client authenticates with a private key JWT', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const key = await registerClientKey(page, client, { tokenEndpointAuthMethod: 'private_key_jwt' });
+
+	const assertion = signJwt(key, await clientAssertionClaims(page, client));
+	let { code } = await authorize(page, client);
+	let res = await postTokenWithAssertion(page, assertion, {
+		grant_type: 'authorization_code',
+		code
+	});
+	expect(res.status()).toBe(200);
+	expect((await res.json()).id_token).toBeDefined();
+
+	// The assertion can only be used once
+	({ code } = await authorize(page, client));
+	res = await postTokenWithAssertion(page, assertion, { grant_type: 'authorization_code', code });
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('invalid_client');
+});
+
+test('Client authenticates with a client secret JWT', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { tokenEndpointAuthMethod: 'client_secret_jwt' });
+	const secretRes = await page.request.post(`/api/oidc/clients/${client.id}/secret`);
+	const clientWithSecret = { ...client, secret: (await secretRes.json()).secret };
+
+	const claims = await clientAssertionClaims(page, client);
+	const assertion = signJwtWithSecret(clientWithSecret.secret, claims);
+	let { code } = await authorize(page, client);
+	let res = await postTokenWithAssertion(page, assertion, {
+		grant_type: 'authorization_code',
+		code
+	});
+	expect(res.status()).toBe(200);
+
+	// The client must not fall back to sending its secret
+	({ code } = await authorize(page, client));
+	res = await postToken(page, clientWithSecret, { grant_type: 'authorization_code', code });
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('invalid_client');
+
+	// Assertions signed with another secret are rejected
+	const forged = signJwtWithSecret('forged', await clientAssertionClaims(page, client));
+	res = await postTokenWithAssertion(page, forged, { grant_type: 'authorization_code', code });
+	expect(res.status()).toBe(401);
+});
+
+test('Client authenticates with a self-signed certificate', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const certificate = readFileSync('tests/assets/client-certificate.pem', 'utf8');
+	const { publicKey, raw } = new X509Certificate(certificate);
+	await updateClient(page, client, {
+		jwks: JSON.stringify({ keys: [publicKey.export({ format: 'jwk' })] }),
+		tokenEndpointAuthMethod: 'self_signed_tls_client_auth'
+	});
+	const headers = { 'X-Client-Cert': encodeURIComponent(certificate) };
+
+	const { code } = await authorize(page, client);
+	const res = await page.request.post('/api/oidc/token', {
+		headers,
+		form: { client_id: client.id, grant_type: 'authorization_code', code }
+	});
+	expect(res.status()).toBe(200);
+	const accessToken = (await res.json()).access_token;
+
+	// The access token is bound to the certificate
+	const thumbprint = createHash('sha256').update(raw).digest('base64url');
+	expect(decodeJwt(accessToken).cnf['x5t#S256']).toBe(thumbprint);
+	const authorization = { Authorization: `Bearer ${accessToken}` };
+	let userinfo = await page.request.get('/api/oidc/userinfo', { headers: authorization });
+	expect(userinfo.ok()).toBeFalsy();
+	userinfo = await page.request.get('/api/oidc/userinfo', {
+		headers: { ...authorization, ...headers }
+	});
+	expect(userinfo.status()).toBe(200);
+});
+
+test('Client certificate must match the registered keys', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await registerClientKey(page, client, { tokenEndpointAuthMethod: 'self_signed_tls_client_auth' });
+	const certificate = readFileSync('tests/assets/client-certificate.pem', 'utf8');
+
+	let { code } = await authorize(page, client);
+	let res = await page.request.post('/api/oidc/token', {
+		headers: { 'X-Client-Cert': encodeURIComponent(certificate) },
+		form: { client_id: client.id, grant_type: 'authorization_code', code }
+	});
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('invalid_client');
+
+	({ code } = await authorize(page, client));
+	res = await page.request.post('/api/oidc/token', {
+		form: { client_id: client.id, grant_type: 'authorization_code', code }
+	});
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('invalid_client');
+});
+
+test('DPoP binds the tokens to the key of the client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const key = generateKeyPairSync('ec', { namedCurve: 'P-256' });
+	const tokenUrl = `${await issuer(page)}/api/oidc/token`;
+	const { code } = await authorize(page, client);
+
+	// The first proof is rejected because it has to contain the nonce of the server
+	const form = { grant_type: 'authorization_code', code };
+	let res = await postToken(page, client, form, { DPoP: dpopProof(key, 'POST', tokenUrl) });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('use_dpop_nonce');
+
+	let nonce = res.headers()['dpop-nonce'];
+	res = await postToken(page, client, form, { DPoP: dpopProof(key, 'POST', tokenUrl, { nonce }) });
+	expect(res.status()).toBe(200);
+	const tokens = await res.json();
+	expect(tokens.token_type).toBe('DPoP');
+	expect(decodeJwt(tokens.access_token).cnf.jkt).toBe(jwkThumbprint(key.publicKey));
+
+	nonce = res.headers()['dpop-nonce'];
+	const ath = createHash('sha256').update(tokens.access_token).digest('base64url');
+	const userinfoUrl = `${await issuer(page)}/api/oidc/userinfo`;
+	res = await page.request.get('/api/oidc/userinfo', {
+		headers: {
+			Authorization: `DPoP ${tokens.access_token}`,
+			DPoP: dpopProof(key, 'GET', userinfoUrl, { nonce, ath })
+		}
+	});
+	expect(res.status()).toBe(200);
+});
+
+test('DPoP-bound tokens require a valid proof', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { requireDpop: true });
+	const key = generateKeyPairSync('ec', { namedCurve: 'P-256' });
+	const tokenUrl = `${await issuer(page)}/api/oidc/token`;
+
+	let { code } = await authorize(page, client);
+	let res = await postToken(page, client, { grant_type: 'authorization_code', code });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_dpop_proof');
+
+	// The proof has to be bound to the method of the request
+	({ code } = await authorize(page, client));
+	const form = { grant_type: 'authorization_code', code };
+	res = await postToken(page, client, form, { DPoP: dpopProof(key, 'POST', tokenUrl) });
+	const nonce = res.headers()['dpop-nonce'];
+	res = await postToken(page, client, form, { DPoP: dpopProof(key, 'GET', tokenUrl, { nonce }) });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_dpop_proof');
+
+	// DPoP-bound access tokens can't be used as bearer tokens
+	res = await postToken(page, client, form, { DPoP: dpopProof(key, 'POST', tokenUrl, { nonce }) });
+	const { access_token } = await res.json();
+	res = await page.request.get('/api/oidc/userinfo', {
+		headers: { Authorization: `Bearer ${access_token}` }
+	});
+	expect(res.ok()).toBeFalsy();
+});
+
+test('Token exchange issues a downscoped token for another client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const targetClient = oidcClients.immich;
+	await updateClient(page, client, { tokenExchangeAudiences: [targetClient.id] });
+	await authorize(page, targetClient);
+
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const exchanged = await requestTokens(page, client, {
+		...tokenExchangeForm(tokens.access_token, targetClient.id),
+		scope: 'openid profile'
+	});
+	expect(exchanged.issued_token_type).toBe('urn:ietf:params:oauth:token-type:access_token');
+	expect(exchanged.scope).toBe('openid profile');
+
+	const claims = decodeJwt(exchanged.access_token);
+	expect(claims.aud).toContain(targetClient.id);
+	expect(claims.act.sub).toBe(client.id);
+});
+
+test('Token exchange is limited to the allowed audiences', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const targetClient = oidcClients.immich;
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const form = tokenExchangeForm(tokens.access_token, targetClient.id);
+
+	let res = await postToken(page, client, form);
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('unauthorized_client');
+
+	await updateClient(page, client, { tokenExchangeAudiences: [client.id] });
+	res = await postToken(page, client, form);
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_target');
+
+	// Only tokens issued to the client itself can be exchanged
+	await updateClient(page, targetClient, { tokenExchangeAudiences: [client.id] });
+	res = await postToken(page, targetClient, tokenExchangeForm(tokens.access_token, client.id));
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_grant');
+});
+
+test('Implicit flow returns the tokens in the fragment', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { implicitFlowEnabled: true });
+
+	const res = await page.request.post('/api/oidc/authorize', {
+		data: {
+			clientID: client.id,
+			scope: 'openid profile',
+			callbackURL: client.callbackUrl,
+			responseType: 'id_token token',
+			nonce: 'implicit-nonce'
+		}
+	});
+	expect(res.status()).toBe(200);
+	const { responseMode, parameters } = await res.json();
+	expect(responseMode).toBe('fragment');
+	expect(parameters.code).toBeUndefined();
+
+	const idToken = decodeJwt(parameters.id_token);
+	expect(idToken.nonce).toBe('implicit-nonce');
+	expect(idToken.at_hash).toBe(leftHalfHash(parameters.access_token));
+});
+
+test('Hybrid flow returns a code that belongs to the ID token', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { implicitFlowEnabled: true });
+
+	const parameters = await authorize(page, client, {
+		responseType: 'code id_token',
+		nonce: 'hybrid-nonce'
+	});
+	expect(decodeJwt(parameters.id_token).c_hash).toBe(leftHalfHash(parameters.code));
+
+	const tokens = await requestTokens(page, client, {
+		grant_type: 'authorization_code',
+		code: parameters.code
+	});
+	expect(decodeJwt(tokens.id_token).nonce).toBe('hybrid-nonce');
+});
+
+test('Implicit flow must be enabled and requires a nonce', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const data = { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl };
+
+	let res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, responseType: 'id_token', nonce: 'nonce' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('unauthorized_client');
+
+	await updateClient(page, client, { implicitFlowEnabled: true });
+	res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, responseType: 'id_token' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+
+	res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, responseType: 'token' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('unsupported_response_type');
+});
+
+test('Form post response mode renders a form that posts the response', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const res = await page.request.post('/api/oidc/authorize', {
+		data: {
+			clientID: client.id,
+			scope: 'openid',
+			callbackURL: client.callbackUrl,
+			responseMode: 'form_post',
+			state: 'form-state'
+		}
+	});
+	expect(res.status()).toBe(200);
+
+	const { responseMode, parameters, formPostHtml } = await res.json();
+	expect(responseMode).toBe('form_post');
+	expect(formPostHtml).toContain(`action="${client.callbackUrl}"`);
+	expect(formPostHtml).toContain(`name="code" value="${parameters.code}"`);
+	expect(formPostHtml).toContain('name="state" value="form-state"');
+});
+
+test('JWT secured response is signed by the server', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const res = await page.request.post('/api/oidc/authorize', {
+		data: {
+			clientID: client.id,
+			scope: 'openid',
+			callbackURL: client.callbackUrl,
+			responseMode: 'jwt',
+			state: 'jwt-state'
+		}
+	});
+	expect(res.status()).toBe(200);
+	const { responseMode, parameters } = await res.json();
+	expect(responseMode).toBe('query');
+	expect(Object.keys(parameters)).toEqual(['response']);
+
+	const claims = await verifyJwt(page, parameters.response);
+	expect(claims.iss).toBe(await issuer(page));
+	expect(claims.aud).toBe(client.id);
+	expect(claims.state).toBe('jwt-state');
+	await requestTokens(page, client, { grant_type: 'authorization_code', code: claims.code });
+});
+
+test('Response mode must fit the response type', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { implicitFlowEnabled: true });
+	const data = { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl };
+
+	let res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, responseType: 'id_token', responseMode: 'query', nonce: 'nonce' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+
+	res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, responseMode: 'web_message' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+});
+
+test('Back-channel logout notifies the client of the ended session', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const receiver = await startLogoutReceiver();
+	await updateClient(page, client, { backchannelLogoutUri: receiver.uri });
+
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const res = await page.request.delete(`/api/oidc/users/${users.tim.id}/sessions`);
+	expect(res.status()).toBe(204);
+
+	const logoutToken = await receiver.logoutToken;
+	receiver.close();
+	const claims = await verifyJwt(page, logoutToken);
+	expect(claims.aud).toBe(client.id);
+	expect(claims.sid).toBe(decodeJwt(tokens.id_token).sid);
+	expect(claims.events).toEqual({ 'http://schemas.openid.net/event/backchannel-logout': {} });
+	expect(claims.nonce).toBeUndefined();
+});
+
+test('Back-channel logout URI must be an absolute URL', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	for (const backchannelLogoutUri of ['/logout', 'https://nextcloud/logout#fragment']) {
+		const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+			data: { name: client.name, callbackURLs: [client.callbackUrl], backchannelLogoutUri }
+		});
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('Front-channel logout loads the logout URIs of the clients', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, {
+		logoutCallbackURLs: ['http://nextcloud/auth/logout/callback'],
+		frontchannelLogoutUri: 'http://nextcloud/auth/logout',
+		frontchannelLogoutSessionRequired: true
+	});
+
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const res = await page.request.get('/api/oidc/end-session', {
+		params: { id_token_hint: tokens.id_token, state: 'logout-state' },
+		maxRedirects: 0
+	});
+	expect(res.status()).toBe(200);
+
+	const html = await res.text();
+	const frameSrc = html.match(/<iframe src="([^"]*)"/)![1].replaceAll('&amp;', '&');
+	const logoutUrl = new URL(frameSrc);
+	expect(logoutUrl.origin + logoutUrl.pathname).toBe('http://nextcloud/auth/logout');
+	expect(logoutUrl.searchParams.get('iss')).toBe(await issuer(page));
+	expect(logoutUrl.searchParams.get('sid')).toBe(decodeJwt(tokens.id_token).sid);
+	expect(html).toContain('"http://nextcloud/auth/logout/callback?state=logout-state"');
+});
+
+test('Front-channel logout requires a valid ID token hint', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+		data: {
+			name: client.name,
+			callbackURLs: [client.callbackUrl],
+			frontchannelLogoutUri: 'nextcloud/auth/logout'
+		}
+	});
+	expect(res.status()).toBe(400);
+
+	// The user has to confirm the logout if the ID token hint is invalid
+	const endSession = await page.request.get('/api/oidc/end-session', {
+		params: { id_token_hint: 'invalid' },
+		maxRedirects: 0
+	});
+	expect(endSession.status()).toBe(302);
+	expect(endSession.headers()['location']).toMatch(/\/logout$/);
+});
+
+test('Silent authorization succeeds for the signed in user', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, {
+		prompt: 'none',
+		loginHint: users.tim.email,
+		maxAge: 3600
+	});
+
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	expect(decodeJwt(tokens.id_token).auth_time).toBeDefined();
+});
+
+test('Silent authorization returns the error to the client', async ({ page }) => {
+	// The user didn't authorize Immich yet
+	let parameters = await authorize(page, oidcClients.immich, { prompt: 'none', state: 'silent' });
+	expect(parameters.error).toBe('consent_required');
+	expect(parameters.state).toBe('silent');
+
+	const client = oidcClients.nextcloud;
+	parameters = await authorize(page, client, { prompt: 'none', loginHint: users.craig.email });
+	expect(parameters.error).toBe('login_required');
+
+	// Without prompt=none the user is asked to sign in again
+	const data = { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl };
+	let res = await page.request.post('/api/oidc/authorize', { data: { ...data, maxAge: 0 } });
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('login_required');
+
+	res = await page.request.post('/api/oidc/authorize', { data: { ...data, prompt: 'none login' } });
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+});
+
+test('Claims parameter adds the requested claims to their target', async ({ page }) => {
+	const client = oidcClients.immich;
+	const claims = {
+		id_token: { email: null, family_name: { value: 'Other' } },
+		userinfo: { preferred_username: { essential: true } }
+	};
+	const { code } = await authorize(page, client, {
+		scope: 'openid',
+		claims: JSON.stringify(claims)
+	});
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	// Claims requested with a value are only returned if the value matches
+	const idToken = decodeJwt(tokens.id_token);
+	expect(idToken.email).toBe(users.tim.email);
+	expect(idToken.family_name).toBeUndefined();
+	expect(idToken.preferred_username).toBeUndefined();
+
+	const res = await page.request.get('/api/oidc/userinfo', {
+		headers: { Authorization: `Bearer ${tokens.access_token}` }
+	});
+	const userinfo = await res.json();
+	expect(userinfo.preferred_username).toBe(users.tim.username);
+	expect(userinfo.email).toBeUndefined();
+});
+
+test('Claims parameter must be valid and match the signed in user', async ({ page }) => {
+	const client = oidcClients.immich;
+	const data = { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl };
+
+	let res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, claims: '{"id_token":' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_request');
+
+	const claims = { id_token: { sub: { value: users.craig.id } } };
+	res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, claims: JSON.stringify(claims) }
+	});
+	expect(res.status()).toBe(401);
+	expect((await res.json()).error).toBe('login_required');
+});
+
+test('Pairwise subject is stable per client and differs between clients', async ({ page }) => {
+	const subjects: string[] = [];
+	for (const client of [oidcClients.nextcloud, oidcClients.nextcloud, oidcClients.immich]) {
+		await updateClient(page, client, { subjectType: 'pairwise' });
+		const { code } = await authorize(page, client);
+		const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+		const sub = decodeJwt(tokens.id_token).sub;
+
+		const res = await page.request.get('/api/oidc/userinfo', {
+			headers: { Authorization: `Bearer ${tokens.access_token}` }
+		});
+		expect((await res.json()).sub).toBe(sub);
+		subjects.push(sub);
+	}
+
+	expect(subjects[0]).not.toBe(users.tim.id);
+	expect(subjects[1]).toBe(subjects[0]);
+	expect(subjects[2]).not.toBe(subjects[0]);
+});
+
+test('Pairwise clients need a single sector', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const settings = [
+		{ subjectType: 'private' },
+		{ subjectType: 'pairwise', callbackURLs: [client.callbackUrl, 'http://other/auth/callback'] },
+		{ subjectType: 'pairwise', sectorIdentifierUri: 'http://nextcloud/sector.json' }
+	];
+	for (const setting of settings) {
+		const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+			data: { name: client.name, callbackURLs: [client.callbackUrl], ...setting }
+		});
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('Client settings define the token lifetimes and signing algorithm', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, {
+		idTokenLifetime: 5,
+		accessTokenLifetime: 10,
+		idTokenSignedResponseAlg: 'PS384'
+	});
+
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	expect(tokens.expires_in).toBe(600);
+
+	const idToken = await verifyJwt(page, tokens.id_token);
+	expect(idToken.exp - idToken.iat).toBe(300);
+	const header = JSON.parse(Buffer.from(tokens.id_token.split('.')[0], 'base64url').toString());
+	expect(header.alg).toBe('PS384');
+
+	const res = await page.request.get('/.well-known/openid-configuration');
+	expect((await res.json()).id_token_signing_alg_values_supported).toContain('PS384');
+});
+
+test('Client settings reject invalid lifetimes and signing algorithms', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const settings = [
+		{ idTokenLifetime: -1 },
+		{ accessTokenLifetime: 1441 },
+		{ idTokenSignedResponseAlg: 'none' },
+		{ idTokenSignedResponseAlg: 'HS256' }
+	];
+	for (const setting of settings) {
+		const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+			data: { name: client.name, callbackURLs: [client.callbackUrl], ...setting }
+		});
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('ID token and userinfo are encrypted to the key of the client', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { publicKey, privateKey } = generateKeyPairSync('rsa', { modulusLength: 2048 });
+	const jwk = { ...publicKey.export({ format: 'jwk' }), kid: 'e2e-enc', use: 'enc' };
+	await updateClient(page, client, {
+		jwks: JSON.stringify({ keys: [jwk] }),
+		idTokenEncryptedResponseAlg: 'RSA-OAEP-256',
+		idTokenEncryptedResponseEnc: 'A256GCM',
+		userinfoEncryptedResponseAlg: 'RSA-OAEP-256',
+		userinfoEncryptedResponseEnc: 'A256GCM'
+	});
+
+	const { code } = await authorize(page, client, { nonce: 'encrypted-nonce' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	// The ID token is a signed JWT nested in the JWE
+	const idToken = await verifyJwt(page, decryptJwe(privateKey, tokens.id_token));
+	expect(idToken.nonce).toBe('encrypted-nonce');
+
+	const res = await page.request.get('/api/oidc/userinfo', {
+		headers: { Authorization: `Bearer ${tokens.access_token}` }
+	});
+	expect(res.headers()['content-type']).toContain('application/jwt');
+	const userinfo = JSON.parse(decryptJwe(privateKey, await res.text()));
+	expect(userinfo.email).toBe(users.tim.email);
+});
+
+test('Userinfo is returned as a signed JWT', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	await updateClient(page, client, { userinfoSignedResponseAlg: 'RS256' });
+
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const res = await page.request.get('/api/oidc/userinfo', {
+		headers: { Authorization: `Bearer ${tokens.access_token}` }
+	});
+	expect(res.headers()['content-type']).toContain('application/jwt');
+
+	const userinfo = await verifyJwt(page, await res.text());
+	expect(userinfo.aud).toBe(client.id);
+	expect(userinfo.sub).toBe(decodeJwt(tokens.id_token).sub);
+});
+
+test('Response encryption requires a supported algorithm and key', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const signingKey = generateKeyPairSync('rsa', { modulusLength: 2048 }).publicKey;
+	const jwks = JSON.stringify({ keys: [{ ...signingKey.export({ format: 'jwk' }), use: 'sig' }] });
+	const settings = [
+		{ idTokenEncryptedResponseAlg: 'RSA-OAEP-256' },
+		{ jwks, idTokenEncryptedResponseAlg: 'RSA-OAEP-256' },
+		{ jwks, idTokenEncryptedResponseAlg: 'RSA1_5' },
+		{ jwks, userinfoSignedResponseAlg: 'PS256' }
+	];
+	for (const setting of settings) {
+		const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+			data: { name: client.name, callbackURLs: [client.callbackUrl], ...setting }
+		});
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('Resource indicators narrow the audience of the access token', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const files = await createResource(page, 'https://files.test', ['files:read'], [client.id]);
+	const calendar = await createResource(page, 'https://calendar.test', [], [client.id]);
+
+	// Requesting a scope of a resource implies the resource
+	const { code } = await authorize(page, client, {
+		scope: 'openid offline_access files:read',
+		resource: [calendar.identifier]
+	});
+	const tokens = await requestTokens(page, client, {
+		grant_type: 'authorization_code',
+		code,
+		resource: files.identifier
+	});
+	let audience = decodeJwt(tokens.access_token).aud;
+	expect(audience).toContain(files.identifier);
+	expect(audience).not.toContain(calendar.identifier);
+
+	const refreshed = await requestTokens(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token,
+		resource: calendar.identifier
+	});
+	audience = decodeJwt(refreshed.access_token).aud;
+	expect(audience).toContain(calendar.identifier);
+	expect(audience).not.toContain(files.identifier);
+});
+
+test('Resource indicators are limited to the allowed resources', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const files = await createResource(page, 'https://files.test', ['files:read'], [client.id]);
+	const admin = await createResource(page, 'https://admin.test', ['admin'], []);
+	const data = { clientID: client.id, scope: 'openid', callbackURL: client.callbackUrl };
+
+	let res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, resource: [admin.identifier] }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_target');
+
+	res = await page.request.post('/api/oidc/authorize', {
+		data: { ...data, scope: 'openid admin' }
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_scope');
+
+	// Only granted resources can be requested at the token endpoint
+	const { code } = await authorize(page, client, { resource: [files.identifier] });
+	res = await postToken(page, client, {
+		grant_type: 'authorization_code',
+		code,
+		resource: admin.identifier
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_target');
+});
+
+test('Pocket ID cannot be registered as a resource', async ({ page }) => {
+	const appUrl = await issuer(page);
+	for (const identifier of [appUrl, `${appUrl}/api`]) {
+		const res = await page.request.post('/api/oidc/resources', {
+			data: { name: 'Pocket ID', identifier, scopes: [] }
+		});
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('Custom scope releases its claims', async ({ page }) => {
+	const res = await page.request.post('/api/oidc/scopes', {
+		data: {
+			name: 'contact',
+			description: 'Contact details',
+			claims: ['email', 'preferred_username']
+		}
+	});
+	expect(res.status()).toBe(201);
+
+	const discovery = await (await page.request.get('/.well-known/openid-configuration')).json();
+	expect(discovery.scopes_supported).toContain('contact');
+
+	const client = oidcClients.immich;
+	const { code } = await authorize(page, client, { scope: 'openid contact' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+	const idToken = decodeJwt(tokens.id_token);
+	expect(idToken.email).toBe(users.tim.email);
+	expect(idToken.preferred_username).toBe(users.tim.username);
+	expect(idToken.given_name).toBeUndefined();
+});
+
+test('Custom scope needs a valid and unique name', async ({ page }) => {
+	let res = await page.request.post('/api/oidc/scopes', { data: { name: 'contact' } });
+	expect(res.status()).toBe(201);
+
+	for (const name of ['contact', 'profile', 'two words']) {
+		res = await page.request.post('/api/oidc/scopes', { data: { name } });
+		expect(res.status()).toBe(400);
+	}
+});
+
+test('Access token follows the JWT profile for access tokens', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid profile groups' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	const header = JSON.parse(Buffer.from(tokens.access_token.split('.')[0], 'base64url').toString());
+	expect(header.typ).toBe('at+jwt');
+
+	const claims = await verifyJwt(page, tokens.access_token);
+	expect(claims.iss).toBe(await issuer(page));
+	expect(claims.sub).toBe(users.tim.id);
+	expect(claims.aud).toContain(client.id);
+	expect(claims.client_id).toBe(client.id);
+	expect(claims.scope).toBe('openid profile groups');
+	expect(claims.jti).toBeDefined();
+	expect(claims.auth_time).toBeDefined();
+	expect(claims.groups).toContain(userGroups.developers.name);
+	expect(claims.groups).toContain(userGroups.designers.name);
+});
+
+test('Only access tokens are accepted by the userinfo endpoint', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	// ID tokens are signed with the same key but have another type
+	const [header, payload, signature] = tokens.access_token.split('.');
+	const forgedPayload = Buffer.from(
+		JSON.stringify({ ...decodeJwt(tokens.access_token), sub: users.craig.id })
+	).toString('base64url');
+	for (const token of [tokens.id_token, `${header}.${forgedPayload}.${signature}`]) {
+		const res = await page.request.get('/api/oidc/userinfo', {
+			headers: { Authorization: `Bearer ${token}` }
+		});
+		expect(res.ok()).toBeFalsy();
+	}
+});
+
+test('Access tokens are not accepted as session', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client);
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	// Without the session cookie the Authorization header is used
+	await page.context().clearCookies();
+	const res = await page.request.get('/api/users/me', {
+		headers: { Authorization: `Bearer ${tokens.access_token}` }
+	});
+	expect(res.status()).toBe(401);
+});
+
+test('Revoking the consent of a client ends its access', async ({ page }) => {
+	const client = oidcClients.nextcloud;
+	const { code } = await authorize(page, client, { scope: 'openid offline_access' });
+	const tokens = await requestTokens(page, client, { grant_type: 'authorization_code', code });
+
+	expect((await authorizedScopes(page))[client.id]).toContain('offline_access');
+
+	let res = await page.request.delete(`/api/users/me/authorized-clients/${client.id}`);
+	expect(res.status()).toBe(204);
+	expect((await authorizedScopes(page))[client.id]).toBeUndefined();
+
+	// The refresh token is revoked and the client has to ask for consent again
+	res = await postToken(page, client, {
+		grant_type: 'refresh_token',
+		refresh_token: tokens.refresh_token
+	});
+	expect(res.status()).toBe(400);
+	expect((await res.json()).error).toBe('invalid_grant');
+
+	const parameters = await authorize(page, client, { prompt: 'none' });
+	expect(parameters.error).toBe('consent_required');
+});
+
+test('Consent of a client that is not authorized cannot be revoked', async ({ page }) => {
+	const res = await page.request.delete(
+		`/api/users/me/authorized-clients/${oidcClients.immich.id}`
+	);
+	expect(res.status()).toBe(404);
+});
+
+// authorize authorizes the client for the signed in user and returns the response parameters
+async function authorize(
+	page: Page,
+	client: { id: string; callbackUrl: string },
+	options: Record<string, unknown> = {}
+) {
+	const res = await page.request.post('/api/oidc/authorize', {
+		data: {
+			clientID: client.id,
+			scope: 'openid profile email',
+			callbackURL: client.callbackUrl,
+			...options
+		}
+	});
+	expect(res.status()).toBe(200);
+	return (await res.json()).parameters;
+}
+
+// postToken sends a request to the token endpoint authenticated with the client secret
+function postToken(
+	page: Page,
+	client: { id: string; secret: string },
+	form: Record<string, string>,
+	headers: Record<string, string> = {}
+) {
+	return page.request.post('/api/oidc/token', {
+		headers: { Authorization: basicAuth(client), ...headers },
+		form
+	});
+}
+
+// requestTokens returns the token response of a successful request to the token endpoint
+async function requestTokens(
+	page: Page,
+	client: { id: string; secret: string },
+	form: Record<string, string>
+) {
+	const res = await postToken(page, client, form);
+	expect(res.status()).toBe(200);
+	return res.json();
+}
+
+function basicAuth(client: { id: string; secret: string }) {
+	return 'Basic ' + Buffer.from(`${client.id}:${client.secret}`).toString('base64');
+}
+
+// updateClient changes the settings of the client as admin
+async function updateClient(
+	page: Page,
+	client: { id: string; name: string; callbackUrl: string },
+	settings: Record<string, unknown>
+) {
+	const res = await page.request.put(`/api/oidc/clients/${client.id}`, {
+		data: { name: client.name, callbackURLs: [client.callbackUrl], ...settings }
+	});
+	expect(res.status()).toBe(200);
+	return res.json();
+}
+
+function decodeJwt(token: string) {
+	return JSON.parse(Buffer.from(token.split('.')[1], 'base64url').toString());
+}
+
+async function requestDeviceAuthorization(page: Page, client: { id: string; secret: string }) {
+	const res = await page.request.post('/api/oidc/device/authorize', {
+		headers: { Authorization: basicAuth(client) },
+		form: { scope: 'openid profile' }
+	});
+	expect(res.status()).toBe(200);
+	return res.json();
+}
+
+// introspect returns the introspection response for the token
+async function introspect(
+	page: Page,
+	client: { id: string; secret: string },
+	form: Record<string, string>
+) {
+	const res = await page.request.post('/api/oidc/introspect', {
+		headers: { Authorization: basicAuth(client) },
+		form
+	});
+	expect(res.status()).toBe(200);
+	return res.json();
+}
+
+function revoke(page: Page, client: { id: string; secret: string }, form: Record<string, string>) {
+	return page.request.post('/api/oidc/revoke', {
+		headers: { Authorization: basicAuth(client) },
+		form
+	});
+}
+
+async function createInitialAccessToken(page: Page) {
+	const res = await page.request.post('/api/oidc/initial-access-tokens', {
+		data: { expiresAt: new Date(Date.now() + 60 * 60 * 1000).toISOString() }
+	});
+	expect(res.status()).toBe(201);
+	return (await res.json()).token;
+}
+
+// pushAuthorizationRequest pushes the authorization request and returns its request URI
+async function pushAuthorizationRequest(
+	page: Page,
+	client: { id: string; secret: string },
+	form: Record<string, string>
+) {
+	const res = await page.request.post('/api/oidc/par', {
+		headers: { Authorization: basicAuth(client) },
+		form
+	});
+	expect(res.status()).toBe(201);
+	return (await res.json()).request_uri;
+}
+
+// registerClientKey registers a new RSA key for the client and returns its private key
+async function registerClientKey(
+	page: Page,
+	client: { id: string; name: string; callbackUrl: string },
+	settings: Record<string, unknown> = {}
+) {
+	const { publicKey, privateKey } = generateKeyPairSync('rsa', { modulusLength: 2048 });
+	const jwk = { ...publicKey.export({ format: 'jwk' }), kid: 'e2e', use: 'sig', alg: 'RS256' };
+	await updateClient(page, client, { jwks: JSON.stringify({ keys: [jwk] }), ...settings });
+	return privateKey;
+}
+
+// signJwt signs the claims with RS256
+function signJwt(key: KeyObject, claims: Record<string, unknown>) {
+	const input = jwtSigningInput('RS256', claims);
+	return `${input}.${sign('sha256', Buffer.from(input), key).toString('base64url')}`;
+}
+
+// signJwtWithSecret signs the claims with HS256
+function signJwtWithSecret(secret: string, claims: Record<string, unknown>) {
+	const input = jwtSigningInput('HS256', claims);
+	return `${input}.${createHmac('sha256', secret).update(input).digest('base64url')}`;
+}
+
+function jwtSigningInput(alg: string, claims: Record<string, unknown>) {
+	const encode = (value: unknown) => Buffer.from(JSON.stringify(value)).toString('base64url');
+	return `${encode({ alg, typ: 'JWT', kid: 'e2e' })}.${encode(claims)}`;
+}
+
+// clientAssertionClaims returns the claims of a client assertion for the token endpoint
+async function clientAssertionClaims(page: Page, client: { id: string }) {
+	return {
+		iss: client.id,
+		sub: client.id,
+		aud: `${await issuer(page)}/api/oidc/token`,
+		jti: randomUUID(),
+		exp: Math.floor(Date.now() / 1000) + 60
+	};
+}
+
+// postTokenWithAssertion authenticates the client with the client assertion at the token endpoint
+function postTokenWithAssertion(page: Page, assertion: string, form: Record<string, string>) {
+	return page.request.post('/api/oidc/token', {
+		form: {
+			client_assertion_type: 'urn:ietf:params:oauth:client-assertion-type:jwt-bearer',
+			client_assertion: assertion,
+			...form
+		}
+	});
+}
+
+async function issuer(page: Page) {
+	const res = await page.request.get('/.well-known/openid-configuration');
+	return (await res.json()).issuer;
+}
+
+// dpopProof signs a DPoP proof for the request with the EC key
+function dpopProof(
+	key: { publicKey: KeyObject; privateKey: KeyObject },
+	htm: string,
+	htu: string,
+	claims: Record<string, string> = {}
+) {
+	const encode = (value: unknown) => Buffer.from(JSON.stringify(value)).toString('base64url');
+	const header = { alg: 'ES256', typ: 'dpop+jwt', jwk: key.publicKey.export({ format: 'jwk' }) };
+	const payload = { jti: randomUUID(), iat: Math.floor(Date.now() / 1000), htm, htu, ...claims };
+	const input = `${encode(header)}.${encode(payload)}`;
+	const signature = sign('sha256', Buffer.from(input), {
+		key: key.privateKey,
+		dsaEncoding: 'ieee-p1363'
+	});
+	return `${input}.${signature.toString('base64url')}`;
+}
+
+// jwkThumbprint returns the RFC 7638 thumbprint of the EC public key
+function jwkThumbprint(publicKey: KeyObject) {
+	const { crv, kty, x, y } = publicKey.export({ format: 'jwk' });
+	return createHash('sha256').update(JSON.stringify({ crv, kty, x, y })).digest('base64url');
+}
+
+function tokenExchangeForm(subjectToken: string, audience: string) {
+	return {
+		grant_type: 'urn:ietf:params:oauth:grant-type:token-exchange',
+		subject_token: subjectToken,
+		subject_token_type: 'urn:ietf:params:oauth:token-type:access_token',
+		audience
+	};
+}
+
+// leftHalfHash returns the at_hash or c_hash of the value for tokens signed with RS256
+function leftHalfHash(value: string) {
+	return createHash('sha256').update(value).digest().subarray(0, 16).toString('base64url');
+}
+
+// verifyJwt verifies the signature of a JWT issued by the server and returns its claims
+async function verifyJwt(page: Page, token: string) {
+	const { keys } = await (await page.request.get('/.well-known/jwks.json')).json();
+	const [header, payload, signature] = token.split('.');
+	const { kid, alg } = JSON.parse(Buffer.from(header, 'base64url').toString());
+	const jwk = keys.find((k: { kid: string }) => k.kid === kid);
+
+	// RS and PS algorithms only differ in the padding
+	const key = {
+		key: createPublicKey({ key: jwk, format: 'jwk' }),
+		padding: alg.startsWith('PS') ? constants.RSA_PKCS1_PSS_PADDING : constants.RSA_PKCS1_PADDING,
+		saltLength: constants.RSA_PSS_SALTLEN_DIGEST
+	};
+	const valid = verify(
+		`sha${alg.slice(2)}`,
+		Buffer.from(`${header}.${payload}`),
+		key,
+		Buffer.from(signature, 'base64url')
+	);
+	expect(valid).toBeTruthy();
+	return decodeJwt(token);
+}
+
+// startLogoutReceiver starts a server the backend can post a back-channel logout token to.
+// The backend runs in a container in CI, so it reaches the server through host.docker.internal.
+async function startLogoutReceiver() {
+	const server = createServer();
+	const logoutToken = new Promise<string>((resolve) => {
+		server.on('request', (req, res) => {
+			let body = '';
+			req.on('data', (chunk) => (body += chunk));
+			req.on('end', () => {
+				res.end();
+				resolve(new URLSearchParams(body).get('logout_token') ?? '');
+			});
+		});
+	});
+	await new Promise<void>((resolve) => server.listen(0, '0.0.0.0', resolve));
+
+	const host = process.env.E2E_RECEIVER_HOST ?? 'host.docker.internal';
+	const { port } = server.address() as { port: number };
+	return { uri: `http://${host}:${port}/logout`, logoutToken, close: () => server.close() };
+}
+
+// decryptJwe decrypts a JWE that was encrypted with RSA-OAEP-256 and A256GCM
+function decryptJwe(privateKey: KeyObject, jwe: string) {
+	const [header, encryptedKey, iv, ciphertext, tag] = jwe.split('.');
+	const { alg, enc } = JSON.parse(Buffer.from(header, 'base64url').toString());
+	expect(`${alg} ${enc}`).toBe('RSA-OAEP-256 A256GCM');
+
+	const cek = privateDecrypt(
+		{ key: privateKey, padding: constants.RSA_PKCS1_OAEP_PADDING, oaepHash: 'sha256' },
+		Buffer.from(encryptedKey, 'base64url')
+	);
+	const decipher = createDecipheriv('aes-256-gcm', cek, Buffer.from(iv, 'base64url'));
+	decipher.setAAD(Buffer.from(header));
+	decipher.setAuthTag(Buffer.from(tag, 'base64url'));
+	const plaintext = decipher.update(Buffer.from(ciphertext, 'base64url'));
+	return Buffer.concat([plaintext, decipher.final()]).toString();
+}
+
+// createResource creates an API resource the clients are allowed to request
+async function createResource(
+	page: Page,
+	identifier: string,
+	scopes: string[],
+	clientIds: string[]
+) {
+	const res = await page.request.post('/api/oidc/resources', {
+		data: { name: new URL(identifier).hostname, identifier, scopes }
+	});
+	expect(res.status()).toBe(201);
+	const resource = await res.json();
+
+	const allowedClientsUrl = `/api/oidc/resources/${resource.id}/allowed-clients`;
+	const allowedClients = await page.request.put(allowedClientsUrl, { data: { clientIds } });
+	expect(allowedClients.ok()).toBeTruthy();
+	return resource;
+}
+
+// authorizedScopes returns the scopes the signed in user granted by client ID
+async function authorizedScopes(page: Page) {
+	const res = await page.request.get('/api/users/me/authorized-clients');
+	const authorizedClients: { scope: string; client: { id: string } }[] = await res.json();
+	return Object.fromEntries(authorizedClients.map((a) => [a.client.id, a.scope]));
+}